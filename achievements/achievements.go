@@ -0,0 +1,66 @@
+// Package achievements defines the badges players unlock by finishing games and the rules for
+// earning them. Like package rating it's pure logic: gathering the facts about a game and storing
+// unlocks is up to the caller.
+package achievements
+
+// Achievement is one badge. Key is what gets stored, so it must never change.
+type Achievement struct {
+	Key         string
+	Name        string
+	Description string
+}
+
+// Thresholds for the counting achievements
+const (
+	MarathonLength   = 10 // letters in the word for Marathon
+	NoHintWinsNeeded = 10 // wins without a hint for No Hints
+	StreakNeeded     = 5  // wins in a row for Streak 5
+)
+
+var (
+	Flawless    = Achievement{Key: "flawless", Name: "Flawless", Description: "Win a game without a single miss"}
+	NoHints     = Achievement{Key: "no_hints", Name: "No Hints", Description: "Win 10 games without taking a hint"}
+	GiantSlayer = Achievement{Key: "giant_slayer", Name: "Giant Slayer", Description: "Beat a higher-rated opponent"}
+	Marathon    = Achievement{Key: "marathon", Name: "Marathon", Description: "Win a game with a word of 10 or more letters"}
+	Streak5     = Achievement{Key: "streak_5", Name: "Streak 5", Description: "Win 5 games in a row"}
+)
+
+// All lists every achievement, in the order profiles show them.
+var All = []Achievement{Flawless, NoHints, GiantSlayer, Marathon, Streak5}
+
+// Result is one player's finished game, as far as achievements are concerned.
+type Result struct {
+	Won            bool
+	Guessed        bool // false for the host of a custom-word game, who never guesses (and earns nothing)
+	Misses         int  // wrong letters this player guessed
+	WordLength     int  // letters to guess in the word
+	Rating         int  // the player's rating before the game
+	OpponentRating int  // the opponent's rating before the game (0 = no rated human opponent)
+	NoHintWins     int  // wins by guessing without a hint so far, this game included
+	Streak         int  // wins by guessing in a row so far, this game included
+}
+
+// Earned returns the achievements a result qualifies for (including ones the player may already have).
+func Earned(r Result) []Achievement {
+	var earned []Achievement
+	// Only a win by solving the word counts: a host wins when their guesser fails, without guessing
+	if !r.Won || !r.Guessed {
+		return earned
+	}
+	if r.Misses == 0 {
+		earned = append(earned, Flawless)
+	}
+	if r.NoHintWins >= NoHintWinsNeeded {
+		earned = append(earned, NoHints)
+	}
+	if r.OpponentRating > r.Rating {
+		earned = append(earned, GiantSlayer)
+	}
+	if r.WordLength >= MarathonLength {
+		earned = append(earned, Marathon)
+	}
+	if r.Streak >= StreakNeeded {
+		earned = append(earned, Streak5)
+	}
+	return earned
+}
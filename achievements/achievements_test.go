@@ -0,0 +1,30 @@
+package achievements
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEarned(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Result
+		want []Achievement
+	}{
+		{"loss", Result{Guessed: true, OpponentRating: 1500, Rating: 1200}, nil},
+		{"win with misses", Result{Won: true, Guessed: true, Misses: 2, WordLength: 5}, nil},
+		{"flawless", Result{Won: true, Guessed: true, WordLength: 5}, []Achievement{Flawless}},
+		{"giant slayer", Result{Won: true, Guessed: true, Misses: 1, Rating: 1200, OpponentRating: 1300}, []Achievement{GiantSlayer}},
+		{"everything", Result{Won: true, Guessed: true, WordLength: 12, Rating: 1200, OpponentRating: 1300, NoHintWins: 10, Streak: 5},
+			[]Achievement{Flawless, NoHints, GiantSlayer, Marathon, Streak5}},
+		// The host of a custom-word game "wins" when the guesser fails: that earns nothing
+		{"custom-word host", Result{Won: true, WordLength: 12, Rating: 1200, OpponentRating: 1300, NoHintWins: 10, Streak: 5}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Earned(tt.r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Earned = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+	"wordgame/achievements"
+	"wordgame/db"
+	"wordgame/logic"
+	"wordgame/models"
+)
+
+// -------- ACHIEVEMENTS --------
+//
+// When a game ends (playGuess, once the game is saved and rated), each human player's result is
+// checked against the rules in package achievements. New unlocks are stored in the achievements
+// table (each badge once per player), pushed to that player's WebSocket connections for the game
+// as an "achievement" message, and listed on their profile. Daily puzzles don't count.
+
+// A badge a player has unlocked, as listed on their profile
+type UnlockedAchievement struct {
+	achievements.Achievement
+	UnlockedAt time.Time
+}
+
+// An achievement just unlocked in a game, waiting to be pushed to its player
+type achievementUnlock struct {
+	seat        string // "1" or "2"
+	player      string
+	achievement achievements.Achievement
+}
+
+// Helper: Each player's versus rating before a game, from its rating_history rows
+// (empty for unsaved games and games against the computer)
+func ratingsBefore(gameRowID int64) (map[string]int, error) {
+	before := make(map[string]int)
+	if gameRowID == 0 {
+		return before, nil
+	}
+	rows, err := db.DB.Query(`
+        SELECT u.username, h.old_rating
+        FROM rating_history h
+        JOIN users u ON h.user_id = u.id
+        WHERE h.game_id = ? AND h.pool = ?
+    `, gameRowID, poolVersus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var username string
+		var r int
+		if err := rows.Scan(&username, &r); err != nil {
+			return nil, err
+		}
+		before[username] = r
+	}
+	return before, rows.Err()
+}
+
+// Helper: A player's wins by guessing without taking the hint, and their current win streak
+// (counted up to achievements.StreakNeeded), from the saved games. Games the player hosted with
+// their own word don't count either way: they didn't guess.
+func winCounts(userID int, username string) (int, int, error) {
+	// Older games didn't record who took the hint: a hinted one only counts if someone else took it
+	var noHintWins int
+	err := db.DB.QueryRow(`
+        SELECT COUNT(*) FROM games
+        WHERE (player_id = ?1 OR player2_id = ?1) AND winner = ?2
+          AND NOT (mode = 'custom' AND player_id = ?1)
+          AND (hint_used = 0 OR (hint_taker != '' AND hint_taker != ?2))
+    `, userID, username).Scan(&noHintWins)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rows, err := db.DB.Query(`
+        SELECT winner FROM games
+        WHERE (player_id = ?1 OR player2_id = ?1) AND finished_at IS NOT NULL
+          AND NOT (mode = 'custom' AND player_id = ?1)
+        ORDER BY finished_at DESC, id DESC
+        LIMIT ?2
+    `, userID, achievements.StreakNeeded)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+	streak, broken := 0, false
+	for rows.Next() {
+		var winner string
+		if err := rows.Scan(&winner); err != nil {
+			return 0, 0, err
+		}
+		if winner != username {
+			broken = true
+		}
+		if !broken {
+			streak++
+		}
+	}
+	return noHintWins, streak, rows.Err()
+}
+
+// Helper: Store an unlock; reports whether it's new (false if the player already had it)
+func unlockAchievement(userID int, a achievements.Achievement, gameRowID int64) (bool, error) {
+	var gameRef sql.NullInt64
+	if gameRowID > 0 {
+		gameRef = sql.NullInt64{Int64: gameRowID, Valid: true}
+	}
+	res, err := db.DB.Exec(`
+        INSERT OR IGNORE INTO achievements (user_id, achievement, game_id) VALUES (?, ?, ?)
+    `, userID, a.Key, gameRef)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Helper: Check both players of a finished game for new achievements and store them.
+// Called right after updateRatings (Giant Slayer needs the ratings from before the game).
+// Send the result to notifyAchievements once the final state is out (see finishGame).
+func checkAchievements(game *models.Game, gameRowID int64) []achievementUnlock {
+	before, err := ratingsBefore(gameRowID)
+	if err != nil {
+		fmt.Println("Achievements error:", err)
+		return nil
+	}
+	var unlocks []achievementUnlock
+	players := []string{game.Player1, game.Player2}
+	for i, player := range players {
+		if player == "" || player == logic.AIPlayerName {
+			continue
+		}
+		seat := i + 1
+		userID, err := lookupUserID(player)
+		if err != nil {
+			fmt.Println("Achievements error: could not find user", player)
+			continue
+		}
+		res := achievements.Result{
+			Won:        game.Winner == player,
+			Guessed:    !(game.CustomWord && seat == 1),
+			Misses:     missesBy(game, seat),
+			WordLength: guessableLength(game.Word),
+		}
+		if !res.Won || !res.Guessed {
+			continue // every achievement needs a win by solving the word
+		}
+		res.Rating, res.OpponentRating = before[player], before[players[1-i]]
+		if res.NoHintWins, res.Streak, err = winCounts(userID, player); err != nil {
+			fmt.Println("Achievements error:", err)
+			continue
+		}
+
+		for _, a := range achievements.Earned(res) {
+			unlocked, err := unlockAchievement(userID, a, gameRowID)
+			if err != nil {
+				fmt.Println("Achievements error:", err)
+				continue
+			}
+			if unlocked {
+				unlocks = append(unlocks, achievementUnlock{seat: strconv.Itoa(seat), player: player, achievement: a})
+			}
+		}
+	}
+	return unlocks
+}
+
+// Helper: Tell each player about their new achievements over the game's WebSocket
+func notifyAchievements(game *models.Game, unlocks []achievementUnlock) {
+	for _, u := range unlocks {
+		sendToSeat(game.ID, u.seat, WSMessage{
+			GameID:  game.ID,
+			Action:  "achievement",
+			Player:  u.player,
+			Payload: u.achievement.Name + ": " + u.achievement.Description,
+		})
+	}
+}
+
+// Helper: A player's unlocked achievements, in the order of achievements.All
+func loadAchievements(userID int) ([]UnlockedAchievement, error) {
+	rows, err := db.DB.Query("SELECT achievement, unlocked_at FROM achievements WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	unlockedAt := make(map[string]time.Time)
+	for rows.Next() {
+		var key string
+		var at time.Time
+		if err := rows.Scan(&key, &at); err != nil {
+			return nil, err
+		}
+		unlockedAt[key] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var unlocked []UnlockedAchievement
+	for _, a := range achievements.All {
+		if at, ok := unlockedAt[a.Key]; ok {
+			unlocked = append(unlocked, UnlockedAchievement{Achievement: a, UnlockedAt: at})
+		}
+	}
+	return unlocked, nil
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"wordgame/achievements"
+	"wordgame/models"
+)
+
+// Helper: Save a finished game won by winner (custom = player1 picked the word)
+func saveTestGame(t *testing.T, player1, player2, winner string, custom bool) *models.Game {
+	t.Helper()
+	game := testGame(t, "fern", player1, player2)
+	game.CustomWord = custom
+	game.Status, game.Winner = "finished", winner
+	if saveFinishedGame(game) == 0 {
+		t.Fatal("saveFinishedGame failed")
+	}
+	return game
+}
+
+// The host of a custom-word game "wins" when the guesser fails, without guessing: that earns
+// no achievements, and doesn't count toward (or break) a win streak.
+func TestCustomHostWinsEarnNothing(t *testing.T) {
+	host := freshUser(t, "ach_host")
+	saveTestGame(t, host, "ach_rival", host, false)
+	var last *models.Game
+	for i := 0; i < achievements.StreakNeeded; i++ {
+		last = saveTestGame(t, host, "ach_guesser", host, true)
+	}
+
+	noHintWins, streak, err := winCounts(testUser(t, host), host)
+	if err != nil {
+		t.Fatalf("winCounts: %v", err)
+	}
+	if noHintWins != 1 || streak != 1 {
+		t.Errorf("winCounts = %d wins without a hint, streak %d; want 1 and 1 (the versus win only)", noHintWins, streak)
+	}
+	if unlocks := checkAchievements(last, 0); len(unlocks) != 0 {
+		t.Errorf("custom-word host unlocked %v", unlocks)
+	}
+}
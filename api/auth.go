@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
+	"strings"
 	"wordgame/db"
+	"wordgame/logic"
+	"wordgame/session"
 	"wordgame/utils"
 
 	"golang.org/x/crypto/bcrypt"
@@ -22,9 +26,10 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	username := r.FormValue("username")
 	password := r.FormValue("password")
 
-	// Query database for the stored password hash for the given username
+	// Query database for the user's ID and stored password hash
+	var userID int
 	var hash string
-	err := db.DB.QueryRow("SELECT password_hash FROM users WHERE LOWER(username) = LOWER(?)", username).Scan(&hash)
+	err := db.DB.QueryRow("SELECT id, password_hash FROM users WHERE LOWER(username) = LOWER(?)", username).Scan(&userID, &hash)
 
 	// If user not found or password doesn't match the hash, show error and re-render login page
 	if err != nil || bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
@@ -34,18 +39,108 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// If authentication succeeds, set a secure cookie with the username
-	http.SetCookie(w, &http.Cookie{
-		Name:     "user",   // Cookie name
-		Value:    username, // Store username in cookie
-		Path:     "/",      // Cookie is valid for all paths
-		HttpOnly: true,     // JavaScript cannot access the cookie (helps prevent XSS)
-	})
+	// If authentication succeeds, start a server-side session (cookie holds only a random token)
+	if err := session.Create(w, r, userID); err != nil {
+		utils.RenderPage(w, r, "login.html", map[string]interface{}{
+			"Error": "Internal server error. Please try again.",
+		})
+		return
+	}
 
 	// Redirect user to the homepage after successful login
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+// Usernames nobody may register (see RegisterHandler and RenameReservedUsers)
+var reservedUsernames = []string{logic.AIPlayerName, "Draw"}
+
+// RenameReservedUsers renames accounts registered before reservedUsernames was enforced, whose
+// names would be taken for the computer or a draw: "Computer" becomes "Computer_12" (by user ID).
+// Their games, hints and rating history follow the new name; winners are only rewritten where the
+// rating history shows the account won, since a bare "Draw" can't be told apart otherwise.
+// Call once after the backfills (they read the names as they were), before serving requests.
+func RenameReservedUsers() {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(reservedUsernames)), ", ")
+	args := make([]interface{}, len(reservedUsernames))
+	for i, name := range reservedUsernames {
+		args[i] = strings.ToLower(name)
+	}
+	rows, err := db.DB.Query("SELECT id, username FROM users WHERE LOWER(username) IN ("+placeholders+")", args...)
+	if err != nil {
+		fmt.Println("Reserved username migration error:", err)
+		return
+	}
+	// Read everything before renaming anything (there's only one database connection)
+	type account struct {
+		id   int
+		name string
+	}
+	var accounts []account
+	for rows.Next() {
+		var a account
+		if err := rows.Scan(&a.id, &a.name); err != nil {
+			fmt.Println("Reserved username migration error:", err)
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+
+	for _, a := range accounts {
+		newName, err := renameUser(a.id, a.name)
+		if err != nil {
+			fmt.Printf("Could not rename user %q: %v\n", a.name, err)
+			continue
+		}
+		fmt.Printf("Renamed user %q to %q (the name is reserved)\n", a.name, newName)
+	}
+}
+
+// Helper: Give user userID (currently oldName) a free "<oldName>_<id>" name, everywhere it's stored
+func renameUser(userID int, oldName string) (string, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	newName := fmt.Sprintf("%s_%d", oldName, userID)
+	for {
+		var taken int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM users WHERE LOWER(username) = LOWER(?)", newName).Scan(&taken); err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			break
+		}
+		newName += "_"
+	}
+
+	updates := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"UPDATE users SET username = ? WHERE id = ?", []interface{}{newName, userID}},
+		{"UPDATE games SET player2_name = ? WHERE player2_id = ?", []interface{}{newName, userID}},
+		// The computer never takes hints, so a matching hint taker in the account's own games is it
+		{`UPDATE games SET hint_taker = ?
+          WHERE hint_taker = ? AND (player_id = ? OR player2_id = ?)`, []interface{}{newName, oldName, userID, userID}},
+		{`UPDATE games SET winner = ?
+          WHERE winner = ? AND id IN (SELECT game_id FROM rating_history WHERE user_id = ? AND result = 'win')`,
+			[]interface{}{newName, oldName, userID}},
+		// Opponents' history rows (against the computer, "Computer" really is the computer)
+		{`UPDATE rating_history SET opponent = ?
+          WHERE opponent = ? AND pool = 'versus' AND game_id IN (SELECT id FROM games WHERE player_id = ? OR player2_id = ?)`,
+			[]interface{}{newName, oldName, userID, userID}},
+	}
+	for _, u := range updates {
+		if _, err := tx.Exec(u.query, u.args...); err != nil {
+			return "", err
+		}
+	}
+	return newName, tx.Commit()
+}
+
 // RegisterPage handles GET requests to the registration page and renders the registration template.
 func RegisterPage(w http.ResponseWriter, r *http.Request) {
 	utils.RenderPage(w, r, "register.html", map[string]interface{}{})
@@ -65,6 +160,17 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Names the game itself writes into Player2 / Winner can't be taken: a user called "Computer"
+	// would be mistaken for the AI, and one called "Draw" would have every win rated as a draw
+	for _, reserved := range reservedUsernames {
+		if strings.EqualFold(username, reserved) {
+			utils.RenderPage(w, r, "register.html", map[string]interface{}{
+				"Error": "That username is reserved.",
+			})
+			return
+		}
+	}
+
 	// Check for existing username (case-insensitive)
 	var existing string
 	err := db.DB.QueryRow(
@@ -97,10 +203,14 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Insert new user
-	_, err = db.DB.Exec(
+	res, err := db.DB.Exec(
 		"INSERT INTO users (username, password_hash) VALUES (?, ?)",
 		username, hash,
 	)
+	var userID int64
+	if err == nil {
+		userID, err = res.LastInsertId()
+	}
 	if err != nil {
 		utils.RenderPage(w, r, "register.html", map[string]interface{}{
 			"Error": "Could not register. Please try again.",
@@ -108,26 +218,20 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log in user automatically: set login cookie and go to homepage
-	http.SetCookie(w, &http.Cookie{
-		Name:     "user",
-		Value:    username,
-		Path:     "/",
-		HttpOnly: true,
-	})
+	// Log in user automatically: start a session and go to homepage
+	if err := session.Create(w, r, int(userID)); err != nil {
+		// Account exists; they can still log in by hand
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 // === LOGOUT HANDLER ===
 
-// LogoutHandler removes the user cookie by setting it with an expired MaxAge,
+// LogoutHandler deletes the session server-side and clears the session cookie,
 // effectively logging the user out and redirecting to the homepage.
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:   "user", // Target the 'user' cookie
-		Value:  "",     // Clear its value
-		Path:   "/",    // Ensure it matches the original cookie path
-		MaxAge: -1,     // Set MaxAge < 0 to delete the cookie immediately
-	})
+	session.Destroy(w, r)
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"wordgame/db"
+)
+
+// The names the game writes into Player2 / Winner can't be registered, in any case.
+func TestRegisterReservedNames(t *testing.T) {
+	for _, name := range []string{"Computer", "computer", "Draw", "DRAW"} {
+		form := url.Values{"username": {name}, "password": {"pw"}}
+		r := httptest.NewRequest("POST", "/register", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		RegisterHandler(w, r)
+
+		if w.Code == http.StatusSeeOther || !strings.Contains(w.Body.String(), "That username is reserved.") {
+			t.Errorf("registering %q: status %d, want the reserved-name error", name, w.Code)
+		}
+		var n int
+		db.DB.QueryRow("SELECT COUNT(*) FROM users WHERE LOWER(username) = LOWER(?)", name).Scan(&n)
+		if n != 0 {
+			t.Errorf("registering %q created an account", name)
+		}
+	}
+}
+
+// Accounts registered as "Computer" or "Draw" before the names were reserved get a new name,
+// and their games and rating history follow it.
+func TestRenameReservedUsers(t *testing.T) {
+	draw := testUser(t, "Draw")
+	rival := testUser(t, "rename_rival")
+	res, err := db.DB.Exec(`
+        INSERT INTO games (word, player_id, status, player2_id, player2_name, winner, mode, hint_taker, finished_at)
+        VALUES ('fern', ?, 'lost', ?, 'Draw', 'Draw', 'versus', 'Draw', CURRENT_TIMESTAMP)
+    `, rival, draw)
+	if err != nil {
+		t.Fatalf("inserting a game: %v", err)
+	}
+	gameRowID, _ := res.LastInsertId()
+	for _, row := range []struct {
+		userID           int
+		opponent, result string
+	}{{draw, "rename_rival", "win"}, {rival, "Draw", "loss"}} {
+		_, err := db.DB.Exec(`
+            INSERT INTO rating_history (user_id, pool, game_id, opponent, result, old_rating, new_rating)
+            VALUES (?, 'versus', ?, ?, ?, 1200, 1200)
+        `, row.userID, gameRowID, row.opponent, row.result)
+		if err != nil {
+			t.Fatalf("inserting rating history: %v", err)
+		}
+	}
+
+	RenameReservedUsers()
+
+	want := "Draw_" + strconv.Itoa(draw)
+	var name string
+	db.DB.QueryRow("SELECT username FROM users WHERE id = ?", draw).Scan(&name)
+	if name != want {
+		t.Fatalf("username = %q, want %q", name, want)
+	}
+	var player2, winner, hintTaker, opponent string
+	db.DB.QueryRow("SELECT player2_name, winner, hint_taker FROM games WHERE id = ?", gameRowID).Scan(&player2, &winner, &hintTaker)
+	db.DB.QueryRow("SELECT opponent FROM rating_history WHERE user_id = ? AND game_id = ?", rival, gameRowID).Scan(&opponent)
+	for what, got := range map[string]string{"player2_name": player2, "winner": winner, "hint_taker": hintTaker, "opponent": opponent} {
+		if got != want {
+			t.Errorf("%s = %q, want %q", what, got, want)
+		}
+	}
+}
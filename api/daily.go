@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"wordgame/db"
+	"wordgame/logic"
+	"wordgame/models"
+	"wordgame/utils"
+	"wordgame/words"
+)
+
+// Misses allowed in the daily puzzle (same for everyone so results are comparable)
+const dailyMaxMisses = 6
+
+// One user's attempt at a day's puzzle, as stored in daily_results
+type dailyResult struct {
+	Username   string
+	Word       string
+	Guesses    []string // in the order they were made
+	Misses     int
+	Status     string // "in_progress", "won", "lost"
+	StartedAt  time.Time
+	FinishedAt sql.NullTime
+}
+
+// Row shown on the daily results page
+type DailyResultEntry struct {
+	Player   string
+	Status   string // "in_progress", "won", "lost"
+	Misses   int
+	Duration string // time taken, e.g. "1m42s" ("" while in progress)
+	Guesses  string // guess sequence, only filled in once the viewer has finished
+}
+
+// Helper: Today's puzzle date in UTC, e.g. "2025-07-14" (everyone switches word at the same moment)
+func dailyDate() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+var (
+	dailySecretOnce  sync.Once
+	dailySecretValue string
+)
+
+// Helper: Server secret used to pick the daily word. DAILY_SECRET if set; otherwise a random
+// secret generated on first use and kept in server_secrets, so the word survives restarts.
+func dailySecret() string {
+	dailySecretOnce.Do(func() {
+		if secret := os.Getenv("DAILY_SECRET"); secret != "" {
+			dailySecretValue = secret
+			return
+		}
+		secret, err := storedSecret("daily")
+		if err != nil {
+			log.Fatalf("Failed to load the daily puzzle secret: %v", err)
+		}
+		dailySecretValue = secret
+	})
+	return dailySecretValue
+}
+
+// InitDailySecret loads (or generates) the daily puzzle secret at startup, so a database
+// problem stops the server instead of the first request. Call after db.InitDB.
+func InitDailySecret() {
+	dailySecret()
+}
+
+// Helper: A secret from server_secrets, generating and saving a random one if there's none yet
+func storedSecret(name string) (string, error) {
+	var secret string
+	err := db.DB.QueryRow("SELECT value FROM server_secrets WHERE name = ?", name).Scan(&secret)
+	if err == nil {
+		return secret, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	log.Printf("Warning: DAILY_SECRET is not set; using a generated secret stored in the database")
+	// OR IGNORE + re-read: if another process got there first, everyone uses its secret
+	if _, err := db.DB.Exec("INSERT OR IGNORE INTO server_secrets (name, value) VALUES (?, ?)", name, hex.EncodeToString(b)); err != nil {
+		return "", err
+	}
+	err = db.DB.QueryRow("SELECT value FROM server_secrets WHERE name = ?", name).Scan(&secret)
+	return secret, err
+}
+
+// Helper: Load a user's attempt for a date (sql.ErrNoRows if they haven't started)
+func loadDailyResult(userID int, date string) (*dailyResult, error) {
+	res := &dailyResult{}
+	var guesses string
+	err := db.DB.QueryRow(`
+        SELECT word, guesses, misses, status, started_at, finished_at
+        FROM daily_results
+        WHERE user_id = ? AND puzzle_date = ?
+    `, userID, date).Scan(&res.Word, &guesses, &res.Misses, &res.Status, &res.StartedAt, &res.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if guesses != "" {
+		res.Guesses = strings.Split(guesses, ",")
+	}
+	return res, nil
+}
+
+// Helper: Load (or, on first visit, start) the user's attempt at today's puzzle
+func startDaily(userID int) (*dailyResult, error) {
+	date := dailyDate()
+	// INSERT OR IGNORE keeps the original row (and start time) if the user already started today
+	_, err := db.DB.Exec(`
+        INSERT OR IGNORE INTO daily_results (user_id, puzzle_date, word, started_at)
+        VALUES (?, ?, ?, ?)
+    `, userID, date, words.DailyWord(date, dailySecret()), time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	return loadDailyResult(userID, date)
+}
+
+// Helper: Rebuild the in-memory game for an attempt by replaying its stored guesses
+func dailyGame(player string, res *dailyResult) *models.Game {
+	game := &models.Game{
+		ID:                  "daily",
+		Word:                res.Word,
+		GuessedLetters:      make(map[string]bool),
+		MaxIncorrectGuesses: dailyMaxMisses,
+		PlayerTurn:          1,
+		Player1:             player,
+		Status:              "in_progress",
+		Daily:               true,
+	}
+	game.DisplayWord = logic.MaskWord(game)
+	for _, letter := range res.Guesses {
+		logic.RegisterGuess(game, letter)
+	}
+	return game
+}
+
+// Helper: Consecutive days solved, ending today (or yesterday, if today isn't solved yet)
+func dailyStreak(userID int) int {
+	rows, err := db.DB.Query(`
+        SELECT puzzle_date FROM daily_results
+        WHERE user_id = ? AND status = 'won'
+        ORDER BY puzzle_date DESC
+    `, userID)
+	if err != nil {
+		log.Println("Daily streak query error:", err)
+		return 0
+	}
+	defer rows.Close()
+
+	streak := 0
+	expected := time.Now().UTC().Truncate(24 * time.Hour)
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			break
+		}
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			break
+		}
+		// Today not solved (yet) doesn't break a streak that ran until yesterday
+		if streak == 0 && day.Equal(expected.AddDate(0, 0, -1)) {
+			expected = day
+		}
+		if !day.Equal(expected) {
+			break
+		}
+		streak++
+		expected = expected.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// Helper: Format how long an attempt took ("" if unfinished)
+func dailyDuration(res *dailyResult) string {
+	if !res.FinishedAt.Valid {
+		return ""
+	}
+	return res.FinishedAt.Time.Sub(res.StartedAt).Round(time.Second).String()
+}
+
+// GET /daily: today's word-of-the-day board (one attempt per user per day)
+func DailyHandler(w http.ResponseWriter, r *http.Request) {
+	player, ok := getUser(w, r)
+	if !ok {
+		return
+	}
+	userID, err := lookupUserID(player)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	res, err := startDaily(userID)
+	if err != nil {
+		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	game := dailyGame(player, res)
+
+	data := map[string]interface{}{
+		"Date":        dailyDate(),
+		"DisplayWord": game.DisplayWord,
+		"Remaining":   game.MaxIncorrectGuesses - game.IncorrectGuesses,
+		"Correct":     getCorrectLetters(game),
+		"Wrong":       getWrongLetters(game),
+		"GameOver":    res.Status != "in_progress",
+		"Won":         res.Status == "won",
+		"Guesses":     strings.Join(res.Guesses, " "),
+		"Duration":    dailyDuration(res),
+		"Streak":      dailyStreak(userID),
+	}
+	// Only reveal the word once the attempt is over
+	if res.Status != "in_progress" {
+		data["Word"] = res.Word
+	}
+
+	// Show (then clear) any error from the last guess
+	if errCookie, err := r.Cookie("error"); err == nil {
+		if msg, decodeErr := url.QueryUnescape(errCookie.Value); decodeErr == nil {
+			data["Error"] = msg
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name: "error", Value: "", Path: "/", MaxAge: -1,
+		})
+	}
+
+	utils.RenderPage(w, r, "daily.html", data)
+}
+
+// POST /daily/guess: apply one letter to the user's daily attempt and save it
+func DailyGuessHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	player, ok := getUser(w, r)
+	if !ok {
+		return
+	}
+	userID, err := lookupUserID(player)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	res, err := startDaily(userID)
+	if err != nil || res.Status != "in_progress" {
+		// Already used today's attempt (or DB trouble): just show the board
+		http.Redirect(w, r, "/daily", http.StatusSeeOther)
+		return
+	}
+
+	r.ParseForm()
+	game := dailyGame(player, res)
+	if err := logic.RegisterGuess(game, r.FormValue("letter")); err != nil {
+		http.SetCookie(w, &http.Cookie{
+			Name:  "error",
+			Value: url.QueryEscape("Invalid guess: " + err.Error()),
+			Path:  "/",
+		})
+		http.Redirect(w, r, "/daily", http.StatusSeeOther)
+		return
+	}
+
+	// Persist the new guess sequence, and the outcome once the puzzle is over
+	status := "in_progress"
+	var finishedAt interface{}
+	if game.Status == "finished" {
+		status = "lost"
+		if logic.IsSolved(game) {
+			status = "won"
+		}
+		finishedAt = time.Now().UTC()
+	}
+	// Only if the guesses are still the ones replayed above: a guess from another tab in the
+	// meantime would otherwise be overwritten
+	result, err := db.DB.Exec(`
+        UPDATE daily_results
+        SET guesses = ?, misses = ?, status = ?, finished_at = ?
+        WHERE user_id = ? AND puzzle_date = ? AND status = 'in_progress' AND guesses = ?
+    `, strings.Join(game.GuessHistory, ","), game.IncorrectGuesses, status, finishedAt, userID, dailyDate(), strings.Join(res.Guesses, ","))
+	if err != nil {
+		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		http.SetCookie(w, &http.Cookie{
+			Name:  "error",
+			Value: url.QueryEscape("Your puzzle changed in another tab; here it is now."),
+			Path:  "/",
+		})
+	}
+
+	http.Redirect(w, r, "/daily", http.StatusSeeOther)
+}
+
+// GET /daily/results: how everyone did today.
+// Words and guess sequences stay hidden until the viewer has finished their own attempt.
+func DailyResultsHandler(w http.ResponseWriter, r *http.Request) {
+	player, ok := getUser(w, r)
+	if !ok {
+		return
+	}
+	userID, err := lookupUserID(player)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	date := dailyDate()
+	finished := false
+	if own, err := loadDailyResult(userID, date); err == nil {
+		finished = own.Status != "in_progress"
+	}
+
+	// Finished attempts first, solvers by fewest misses then fastest time
+	rows, err := db.DB.Query(`
+        SELECT u.username, d.word, d.guesses, d.misses, d.status, d.started_at, d.finished_at
+        FROM daily_results d
+        JOIN users u ON d.user_id = u.id
+        WHERE d.puzzle_date = ?
+        ORDER BY d.status = 'in_progress', d.status = 'lost', d.misses ASC,
+                 julianday(d.finished_at) - julianday(d.started_at) ASC
+    `, date)
+	if err != nil {
+		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var entries []DailyResultEntry
+	word := ""
+	for rows.Next() {
+		res := &dailyResult{}
+		var guesses string
+		if err := rows.Scan(&res.Username, &res.Word, &guesses, &res.Misses, &res.Status, &res.StartedAt, &res.FinishedAt); err != nil {
+			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		entry := DailyResultEntry{
+			Player:   res.Username,
+			Status:   res.Status,
+			Misses:   res.Misses,
+			Duration: dailyDuration(res),
+		}
+		if finished {
+			entry.Guesses = strings.ReplaceAll(guesses, ",", " ")
+			word = res.Word
+		}
+		entries = append(entries, entry)
+	}
+
+	utils.RenderPage(w, r, "daily_results.html", map[string]interface{}{
+		"Date":     date,
+		"Finished": finished,
+		"Word":     word,
+		"Entries":  entries,
+		"Streak":   dailyStreak(userID),
+		"Summary":  fmt.Sprintf("%d players today", len(entries)),
+	})
+}
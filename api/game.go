@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -12,6 +13,8 @@ import (
 	"wordgame/db"
 	"wordgame/logic"
 	"wordgame/models"
+	"wordgame/session"
+	"wordgame/store"
 	"wordgame/utils"
 	"wordgame/words"
 )
@@ -19,25 +22,82 @@ import (
 // Broadcast channel for websocket messages; buffered for up to 16 messages
 var wsBroadcast = make(chan WSMessage, 16)
 
-// In-memory map to hold all games; key is game ID, value: pointer to game struct
-var games = make(map[string]*models.Game)
+// All live games, keyed by game ID, shared by HTTP handlers and WebSocket goroutines.
+// Only touch a game inside games.View/games.Update (they hold that game's lock).
+// Backed by SQLite so games in progress survive a restart (see RestoreGames).
+var (
+	liveGames                 = store.NewSQLiteStore()
+	games     store.GameStore = liveGames
+)
+
+// Source of secret words for new games (embedded dictionary, word file, or remote API; see WORD_SOURCE)
+var wordProvider = words.NewProviderFromEnv()
 
-// Helper: Retrieve the current logged-in user from cookie.
-// If missing or invalid, redirect to login and return ("", false).
+// Helper: Retrieve the current logged-in user from the session (see session.Middleware).
+// If not logged in, redirect to login and return ("", false).
 func getUser(w http.ResponseWriter, r *http.Request) (string, bool) {
-	cookie, err := r.Cookie("user")
-	if err != nil || cookie.Value == "" {
+	user, ok := session.FromContext(r.Context())
+	if !ok {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return "", false
 	}
-	return cookie.Value, true
+	return user.Username, true
 }
 
-// Helper: Set cookies for game state (game id, player name, player role ID, e.g. "1" or "2")
-func setGameCookies(w http.ResponseWriter, id, player, role string) {
+// Helper: Look up a user's numeric ID by username
+func lookupUserID(username string) (int, error) {
+	var id int
+	err := db.DB.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&id)
+	return id, err
+}
+
+// Helper: Remember which game this browser is in. Only the game ID is stored client-side:
+// the player's seat is always worked out on the server (see seatOf).
+func setGameCookie(w http.ResponseWriter, id string) {
 	http.SetCookie(w, &http.Cookie{Name: "game_id", Value: id, Path: "/"})
-	http.SetCookie(w, &http.Cookie{Name: "player_name", Value: player, Path: "/"})
-	http.SetCookie(w, &http.Cookie{Name: "role", Value: role, Path: "/"})
+}
+
+// Helper: Username of the logged-in user ("" if not logged in)
+func requestUser(r *http.Request) string {
+	if user, ok := session.FromContext(r.Context()); ok {
+		return user.Username
+	}
+	return ""
+}
+
+// Helper: Which seat a user holds in a game: "1", "2", or "" for spectators / not logged in.
+// Caller holds the game's lock.
+func seatOf(game *models.Game, username string) string {
+	switch {
+	case username == "":
+		return ""
+	case username == game.Player1:
+		return "1"
+	case username == game.Player2 && game.Player2 != logic.AIPlayerName:
+		return "2"
+	default:
+		return ""
+	}
+}
+
+// Helper: True if the logged-in user holds a seat in the game
+func holdsSeat(r *http.Request, gameID string) bool {
+	seated := false
+	games.View(gameID, func(game *models.Game) error {
+		seated = seatOf(game, requestUser(r)) != ""
+		return nil
+	})
+	return seated
+}
+
+// Helper: Store an error message in a cookie and send the user back to the home page
+func redirectWithError(w http.ResponseWriter, r *http.Request, msg string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:  "error",
+		Value: url.QueryEscape(msg),
+		Path:  "/",
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 // Helper: Create a unique 4-letter game ID from random lower-case letters
@@ -51,6 +111,29 @@ func generateGameID() string {
 	return string(b)
 }
 
+// Helper: Add a new game to the store under a fresh, unused game ID
+// (games waiting for player 2 show up in the lobby)
+func registerGame(game *models.Game) error {
+	game.Candidates = logic.CountCandidates(game)
+	for {
+		game.ID = generateGameID()
+		err := games.Create(game)
+		if err == store.ErrExists {
+			continue
+		}
+		if err == nil && game.Status == "waiting" {
+			lobbyChanged()
+		}
+		return err
+	}
+}
+
+// RestoreGames reloads games that were in progress when the server last stopped.
+// Call once after db.InitDB, before serving requests.
+func RestoreGames() {
+	liveGames.Restore()
+}
+
 // Helper: Convert string to int, with fallback to default if not valid/positive
 func parseIntWithDefault(s string, def int) int {
 	n, err := strconv.Atoi(s)
@@ -60,6 +143,196 @@ func parseIntWithDefault(s string, def int) int {
 	return n
 }
 
+// Word options chosen on the create-game forms
+type wordSettings struct {
+	Length            int              // letters in the word (0 = any, only allowed with a difficulty)
+	Category          string           // category key ("" = any word)
+	Difficulty        words.Difficulty // requested tier ("" = any)
+	Language          *words.Language  // word list, alphabet and letter frequencies
+	AccentInsensitive bool             // guessing "a" also reveals "á", "ã", ...
+}
+
+// Raw game options as submitted by the create-game forms or the JSON API (unvalidated)
+type gameOptions struct {
+	WordLength        int    `json:"word_length"` // 0 = not given
+	MaxGuesses        int    `json:"max_guesses"` // 0 = default (7)
+	Difficulty        string `json:"difficulty"`
+	Category          string `json:"category"`
+	Language          string `json:"language"`
+	AccentInsensitive bool   `json:"accent_insensitive"`
+	AIStrategy        string `json:"ai_strategy"` // games against the computer only ("" = default)
+}
+
+// Helper: Read game options from the create-game forms
+func parseGameOptions(r *http.Request) gameOptions {
+	return gameOptions{
+		WordLength:        parseIntWithDefault(r.FormValue("word_length"), 0),
+		MaxGuesses:        parseIntWithDefault(r.FormValue("max_guesses"), 0),
+		Difficulty:        r.FormValue("difficulty"),
+		Category:          r.FormValue("category"),
+		Language:          r.FormValue("language"),
+		AccentInsensitive: r.FormValue("accent_insensitive") != "",
+		AIStrategy:        r.FormValue("ai_strategy"),
+	}
+}
+
+// Helper: Misses allowed, falling back to the default of 7
+func (o gameOptions) maxGuesses() int {
+	if o.MaxGuesses <= 0 {
+		return 7
+	}
+	return o.MaxGuesses
+}
+
+// Helper: Validate the word options.
+// Word length may be left out when a difficulty is chosen (stored as 0 = any length).
+func (o gameOptions) wordSettings() (wordSettings, error) {
+	difficulty, err := words.ParseDifficulty(o.Difficulty)
+	if err != nil {
+		return wordSettings{}, err
+	}
+	lang, err := words.LanguageByCode(o.Language)
+	if err != nil {
+		return wordSettings{}, err
+	}
+	settings := wordSettings{
+		Category:          strings.ToLower(o.Category),
+		Difficulty:        difficulty,
+		Language:          lang,
+		AccentInsensitive: o.AccentInsensitive,
+	}
+	if o.WordLength > 0 {
+		settings.Length = words.ClampLength(o.WordLength)
+	} else if difficulty == words.DifficultyAny {
+		settings.Length = 5
+	}
+	return settings, nil
+}
+
+// Helper: Pick the secret word for a new game and report its difficulty tier.
+// Plain English games use the configured wordProvider; otherwise the category list or the
+// language's bundled dictionary is filtered by length (0 = any) and difficulty.
+// Categories are English-only.
+func pickWord(settings wordSettings) (string, words.Difficulty, error) {
+	english := settings.Language.Code == words.DefaultLanguage
+	if english && settings.Category == "" && settings.Difficulty == words.DifficultyAny {
+		word, err := wordProvider.RandomWord(settings.Length)
+		if err != nil {
+			return "", "", err
+		}
+		return word, words.Default().DifficultyOf(word), nil
+	}
+
+	dict := settings.Language.Dictionary()
+	if settings.Category != "" {
+		if !english {
+			return "", "", fmt.Errorf("categories are only available in English")
+		}
+		var err error
+		if dict, err = words.CategoryDictionary(settings.Category); err != nil {
+			return "", "", err
+		}
+	}
+	word, err := dict.RandomWithDifficulty(settings.Length, settings.Difficulty)
+	if err != nil {
+		return "", "", err
+	}
+	return word, dict.DifficultyOf(word), nil
+}
+
+// Helper: Build a fresh game (no players or ID yet) for the chosen settings and word.
+func newGame(word string, rated words.Difficulty, settings wordSettings, maxGuesses int) *models.Game {
+	game := &models.Game{
+		Word:                word,
+		Category:            settings.Category,
+		Difficulty:          string(rated),
+		Language:            settings.Language.Code,
+		AccentInsensitive:   settings.AccentInsensitive,
+		GuessedLetters:      make(map[string]bool),
+		MaxIncorrectGuesses: maxGuesses,
+		PlayerTurn:          1,
+		StartedAt:           time.Now(),
+	}
+	game.DisplayWord = logic.MaskWord(game)
+	return game
+}
+
+// Errors from createGame / joinGame (shared by the HTML handlers and the JSON API)
+var (
+	errBadSettings = errors.New("invalid game settings")
+	errNoWord      = errors.New("no word matches those settings")
+	errGameFull    = errors.New("game already has two players")
+	errOwnGame     = errors.New("can't join your own game")
+)
+
+// Helper: Pick a word and register a new game hosted by player.
+// Human-vs-human games wait for player 2; games against the AI start immediately.
+// Returns the new game's ID.
+func createGame(player string, opts gameOptions, vsAI bool) (string, error) {
+	settings, err := opts.wordSettings()
+	if err != nil {
+		return "", errBadSettings
+	}
+	strategy, err := logic.AIStrategyByKey(opts.AIStrategy)
+	if vsAI && err != nil {
+		return "", errBadSettings
+	}
+	word, rated, err := pickWord(settings)
+	if err != nil {
+		return "", errNoWord
+	}
+
+	game := newGame(word, rated, settings, opts.maxGuesses())
+	game.Player1 = player
+	game.Status = "waiting"
+	if vsAI {
+		// Note Player2 is "Computer" and status is "in_progress" immediately
+		game.Player2 = logic.AIPlayerName
+		game.Status = "in_progress"
+		game.AIStrategy = strategy.Key
+	}
+	// Store new game (gets its ID here)
+	if err := registerGame(game); err != nil {
+		return "", err
+	}
+	return game.ID, nil
+}
+
+// Helper: Seat player as player 2 and start the game.
+// The host's waiting room hears about it over the game's WebSocket, and the game leaves the lobby.
+func joinGame(gameID, player string) error {
+	err := games.Update(gameID, func(game *models.Game) error {
+		if game.Player2 != "" {
+			return errGameFull
+		}
+		if game.Player1 == player {
+			return errOwnGame
+		}
+		// Add player2 and start game (the clock starts now, not when the host created it)
+		game.Player2 = player
+		game.Status = "in_progress"
+		game.StartedAt = time.Now()
+		return nil
+	})
+	if err == nil {
+		BroadcastToClients(WSMessage{GameID: gameID, Action: "state"})
+		lobbyChanged()
+	}
+	return err
+}
+
+// Helper: Redirect home with the message for a createGame error
+func redirectCreateError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case errBadSettings:
+		redirectWithError(w, r, "Invalid game settings.")
+	case errNoWord:
+		redirectWithError(w, r, "Could not pick a word with those settings. Please try another length, category or language.")
+	default:
+		redirectWithError(w, r, "Could not create the game. Please try again.")
+	}
+}
+
 // HTTP POST handler: create new HUMAN-vs-HUMAN game
 func CreateGameHandler(w http.ResponseWriter, r *http.Request) {
 	// Check login & get player name
@@ -70,25 +343,14 @@ func CreateGameHandler(w http.ResponseWriter, r *http.Request) {
 
 	r.ParseForm() // Parse POST form fields
 
-	// Get word length & guesses, falling back to defaults
-	wordLength := parseIntWithDefault(r.FormValue("word_length"), 5)
-	maxGuesses := parseIntWithDefault(r.FormValue("max_guesses"), 7)
-	word := words.GetRandomWord(wordLength)
-	id := generateGameID()
-
-	// Store new game in memory
-	games[id] = &models.Game{
-		ID:                  id,
-		Word:                word,
-		DisplayWord:         strings.Repeat("_ ", len(word)),
-		GuessedLetters:      make(map[string]bool),
-		MaxIncorrectGuesses: maxGuesses,
-		Player1:             player,
-		PlayerTurn:          1,
-		Status:              "waiting",
+	// Word settings & guesses come from the form, falling back to defaults
+	id, err := createGame(player, parseGameOptions(r), false)
+	if err != nil {
+		redirectCreateError(w, r, err)
+		return
 	}
 
-	setGameCookies(w, id, player, "1")                // Set player 1 role cookies
+	setGameCookie(w, id)                              // Remember the game (player 1's seat comes from the session)
 	http.Redirect(w, r, "/wait", http.StatusSeeOther) // Go to waiting room
 }
 
@@ -101,33 +363,20 @@ func JoinGameHandler(w http.ResponseWriter, r *http.Request) {
 
 	r.ParseForm()
 	gameID := r.FormValue("game_id")
-	game, found := games[gameID]
-	if !found {
+	err := joinGame(gameID, player)
+	if err == store.ErrNotFound {
 		// Set error message and redirect if can't find game
-		http.SetCookie(w, &http.Cookie{
-			Name:  "error",
-			Value: url.QueryEscape("Game not found."),
-			Path:  "/",
-		})
-		http.Redirect(w, r, "/", http.StatusSeeOther)
+		redirectWithError(w, r, "Game not found.")
 		return
-	}
-
-	if game.Player2 != "" {
+	} else if err == errOwnGame {
+		redirectWithError(w, r, "You can't join your own game. Share the code with a friend.")
+		return
+	} else if err != nil {
 		// Already has two players
-		http.SetCookie(w, &http.Cookie{
-			Name:  "error",
-			Value: url.QueryEscape("Game already has two players."),
-			Path:  "/",
-		})
-		http.Redirect(w, r, "/", http.StatusSeeOther)
+		redirectWithError(w, r, "Game already has two players.")
 		return
 	}
-
-	// Add player2 and start game
-	game.Player2 = player
-	game.Status = "in_progress"
-	setGameCookies(w, gameID, player, "2")
+	setGameCookie(w, gameID)
 	http.Redirect(w, r, "/wait", http.StatusSeeOther)
 }
 
@@ -139,46 +388,88 @@ func CreateAIHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	r.ParseForm()
-	wordLength := parseIntWithDefault(r.FormValue("word_length"), 5)
+	id, err := createGame(player, parseGameOptions(r), true)
+	if err != nil {
+		redirectCreateError(w, r, err)
+		return
+	}
+
+	setGameCookie(w, id)
+	http.Redirect(w, r, "/gameplay", http.StatusSeeOther)
+}
+
+// HTTP POST handler: create a "host picks the word" game.
+// Player 1 types the secret word and watches live; Player 2 joins with the code and guesses alone.
+func CreateCustomHandler(w http.ResponseWriter, r *http.Request) {
+	player, ok := getUser(w, r)
+	if !ok {
+		return
+	}
+
+	r.ParseForm()
+	lang, err := words.LanguageByCode(r.FormValue("language"))
+	if err != nil {
+		redirectWithError(w, r, "Invalid game settings.")
+		return
+	}
+	word, err := words.ValidateCustomWord(r.FormValue("secret_word"), lang)
+	if err != nil {
+		redirectWithError(w, r, "Secret word rejected: "+err.Error()+".")
+		return
+	}
 	maxGuesses := parseIntWithDefault(r.FormValue("max_guesses"), 7)
-	word := words.GetRandomWord(wordLength)
-	id := generateGameID()
 
-	// Note Player2 is "Computer" and status is "in_progress" immediately
-	games[id] = &models.Game{
-		ID:                  id,
-		Word:                word,
-		DisplayWord:         strings.Repeat("_ ", len(word)),
-		GuessedLetters:      make(map[string]bool),
-		MaxIncorrectGuesses: maxGuesses,
-		Player1:             player,
-		Player2:             "Computer",
-		PlayerTurn:          1,
-		Status:              "in_progress",
+	settings := wordSettings{
+		Language:          lang,
+		AccentInsensitive: r.FormValue("accent_insensitive") != "",
+	}
+	game := newGame(word, lang.Dictionary().DifficultyOf(word), settings, maxGuesses)
+	game.Player1 = player
+	game.CustomWord = true
+	game.PlayerTurn = 2 // only the guesser ever takes a turn
+	game.Status = "waiting"
+	if err := registerGame(game); err != nil {
+		redirectWithError(w, r, "Could not create the game. Please try again.")
+		return
 	}
 
-	setGameCookies(w, id, player, "1")
-	http.Redirect(w, r, "/gameplay", http.StatusSeeOther)
+	setGameCookie(w, game.ID)
+	http.Redirect(w, r, "/wait", http.StatusSeeOther)
 }
 
 // Wait room handler: shows "waiting for player 2", or advances if ready
 func WaitRoomHandler(w http.ResponseWriter, r *http.Request) {
+	// Matchmaking sends both players here with ?game_id= (their browsers don't have the game cookie yet)
+	if id := r.URL.Query().Get("game_id"); id != "" && holdsSeat(r, id) {
+		setGameCookie(w, id)
+		http.Redirect(w, r, "/wait", http.StatusSeeOther)
+		return
+	}
+
 	gameIDCookie, err := r.Cookie("game_id")
 	if err != nil {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
 	}
-	game, ok := games[gameIDCookie.Value]
-	if !ok {
+	var data map[string]interface{}
+	ready := false
+	err = games.View(gameIDCookie.Value, func(game *models.Game) error {
+		if game.Player2 == "" && game.Player2 != "Computer" {
+			// Still waiting for second player
+			data = map[string]interface{}{
+				"GameID":  game.ID,
+				"Player1": game.Player1,
+			}
+		} else {
+			ready = true
+		}
+		return nil
+	})
+	if err != nil {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
 	}
-	if game.Player2 == "" && game.Player2 != "Computer" {
-		// Still waiting for second player
-		data := map[string]interface{}{
-			"GameID":  game.ID,
-			"Player1": game.Player1,
-		}
+	if !ready {
 		utils.RenderPage(w, r, "waiting.html", data)
 	} else {
 		// Ready to play
@@ -193,65 +484,79 @@ func GameplayHandler(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
 	}
-	game, ok := games[cookie.Value]
-	if !ok {
+	var data map[string]interface{}
+	err = games.View(cookie.Value, func(game *models.Game) error {
+		data = gameplayData(r, game)
+		return nil
+	})
+	if err != nil {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
 	}
 
+	// Get and display any error messages, then clear the cookie
+	if errCookie, err := r.Cookie("error"); err == nil {
+		if msg, decodeErr := url.QueryUnescape(errCookie.Value); decodeErr == nil {
+			data["Error"] = msg
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name: "error", Value: "", Path: "/", MaxAge: -1,
+		})
+	}
+
+	utils.RenderPage(w, r, "gameplay.html", data)
+}
+
+// Helper: Build the gameplay.html data for one player; caller holds the game's lock.
+// Only copied fields go to the template, never the game itself (its Word is secret; see visibleWord).
+func gameplayData(r *http.Request, game *models.Game) map[string]interface{} {
 	lastGuess := ""
 	if len(game.GuessHistory) > 0 {
 		lastGuess = game.GuessHistory[len(game.GuessHistory)-1]
 	}
 
 	// Build data for template: game state, guess history, winner, etc.
-	data := map[string]interface{}{
-		"Game":         game,
+	return map[string]interface{}{
+		"GameID":       game.ID,
+		"CustomWord":   game.CustomWord,
 		"Player1":      game.Player1,
 		"Player2":      game.Player2,
-		"Word":         game.Word,
+		"Word":         visibleWord(game, seatOf(game, requestUser(r))),
+		"Category":     game.Category,
+		"Difficulty":   game.Difficulty,
+		"Language":     logic.GameLanguage(game).Name,
+		"Alphabet":     string(logic.GameLanguage(game).Alphabet()),
 		"DisplayWord":  game.DisplayWord,
 		"Remaining":    game.MaxIncorrectGuesses - game.IncorrectGuesses,
+		"Candidates":   game.Candidates,
 		"Correct":      getCorrectLetters(game),
 		"Wrong":        getWrongLetters(game),
 		"IsPlayerTurn": isPlayerTurn(r, game),
+		"IsSetter":     isSetter(r, game),
 		"GameOver":     game.Status == "finished",
 		"Winner":       game.Winner,
 		"HasUsedHint":  game.HasUsedHint,
 		"HintText":     game.HintText,
 		"LastGuess":    lastGuess,
+		"Spectators":   spectatorCount(game.ID),
 	}
-
-	// Get and display any error messages, then clear the cookie
-	if errCookie, err := r.Cookie("error"); err == nil {
-		if msg, decodeErr := url.QueryUnescape(errCookie.Value); decodeErr == nil {
-			data["Error"] = msg
-		}
-		http.SetCookie(w, &http.Cookie{
-			Name: "error", Value: "", Path: "/", MaxAge: -1,
-		})
-	}
-
-	utils.RenderPage(w, r, "gameplay.html", data)
 }
 
-// Return true if the current player (from cookie) is the one whose turn it is
+// Return true if the logged-in user holds the seat whose turn it is
 func isPlayerTurn(r *http.Request, game *models.Game) bool {
-	cookie, err := r.Cookie("player_name")
-	if err != nil {
-		return false
-	}
-	player := cookie.Value
-	// Compare which player turn we are at
-	return (game.PlayerTurn == 1 && game.Player1 == player) ||
-		(game.PlayerTurn == 2 && game.Player2 == player)
+	return seatOf(game, requestUser(r)) == strconv.Itoa(game.PlayerTurn)
+}
+
+// Return true if the logged-in user chose the word in a custom-word game
+func isSetter(r *http.Request, game *models.Game) bool {
+	return game.CustomWord && seatOf(game, requestUser(r)) == "1"
 }
 
 // Retrieve list of correct guessed letters, sorted alphabetically, as a string with commas
 func getCorrectLetters(game *models.Game) string {
 	letters := []string{}
 	for letter := range game.GuessedLetters {
-		if strings.Contains(game.Word, letter) {
+		if logic.InWord(game, letter) {
 			letters = append(letters, letter)
 		}
 	}
@@ -263,7 +568,7 @@ func getCorrectLetters(game *models.Game) string {
 func getWrongLetters(game *models.Game) string {
 	letters := []string{}
 	for letter := range game.GuessedLetters {
-		if !strings.Contains(game.Word, letter) {
+		if !logic.InWord(game, letter) {
 			letters = append(letters, letter)
 		}
 	}
@@ -276,11 +581,147 @@ func GuessHandler(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "Use WebSocket to guess", http.StatusMethodNotAllowed)
 }
 
-// Helper: build the per-game, per player template state as a map
-func buildGameState(game *models.Game, role string) map[string]interface{} {
+// A guess the server refused: the message is shown to the player as-is
+type guessError struct {
+	status int    // HTTP status for the JSON API
+	msg    string // e.g. "It's not your turn."
+}
+
+func (e *guessError) Error() string { return e.msg }
+
+// Reasons a guess is refused (see playGuess)
+var (
+	errGameOver    = &guessError{http.StatusConflict, "The game is over."}
+	errNotPlayer   = &guessError{http.StatusForbidden, "You're not a player in this game, so you can only watch."}
+	errSetterGuess = &guessError{http.StatusForbidden, "You chose the word, so you can only watch."}
+	errNotStarted  = &guessError{http.StatusConflict, "The game hasn't started yet."}
+	errNotYourTurn = &guessError{http.StatusConflict, "It's not your turn."}
+)
+
+// Helper: Apply one guess from username, everything a guess triggers, and the live broadcast.
+// Shared by the WebSocket and JSON API so both follow exactly the same rules.
+// Only checking and registering a guess runs under the game's lock, so two guesses can't
+// interleave; the computer's reply, saving the result and the broadcasts happen after it is
+// released. Refused guesses return a *guessError (or store.ErrNotFound).
+func playGuess(gameID, username, payload string) error {
+	var aiTurn, finished *models.Game // copies taken under the lock, for the work done after it
+	err := games.Update(gameID, func(game *models.Game) error {
+		if game.Status == "finished" {
+			return errGameOver
+		}
+
+		// Only the seated player whose turn it is may guess
+		seat := seatOf(game, username)
+		switch {
+		case seat == "":
+			return errNotPlayer
+		case game.CustomWord && seat == "1":
+			// Custom-word games: the host only watches their own word being guessed.
+			return errSetterGuess
+		case game.Status != "in_progress":
+			return errNotStarted
+		case seat != strconv.Itoa(game.PlayerTurn):
+			return errNotYourTurn
+		}
+
+		// Validate guess: must be a single letter of the game's alphabet (accent-folded if enabled)
+		letter, err := logic.NormalizeGuess(game, payload)
+		if err != nil {
+			return &guessError{http.StatusBadRequest, "Invalid guess: " + err.Error() + "."}
+		}
+		if game.GuessedLetters[letter] {
+			return &guessError{http.StatusConflict, fmt.Sprintf("Letter '%s' has already been guessed.", letter)}
+		}
+
+		// Register the guess (update game state accordingly)
+		applyGuess(game, letter)
+
+		switch {
+		case game.Status == "finished":
+			finished = copyGame(game)
+		case game.Player2 == logic.AIPlayerName && game.PlayerTurn == 2:
+			// Single-player vs AI and it's now the computer's turn (the human can't guess meanwhile)
+			aiTurn = copyGame(game)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Broadcast updated state to *all* WebSocket clients for this game
+	BroadcastToClients(WSMessage{GameID: gameID, Action: "state"})
+
+	if aiTurn != nil {
+		// The AI may take a while (Gemini is a network call), so it works on the copy, unlocked
+		aiGuess := logic.AIGuess(aiTurn)
+		err := games.Update(gameID, func(game *models.Game) error {
+			// Apply it only if nothing happened to the game in the meantime
+			if game.Status != "in_progress" || game.PlayerTurn != 2 || len(game.GuessHistory) != len(aiTurn.GuessHistory) {
+				return nil
+			}
+			applyGuess(game, aiGuess)
+			if game.Status == "finished" {
+				finished = copyGame(game)
+			}
+			return nil
+		})
+		if err == nil {
+			BroadcastToClients(WSMessage{GameID: gameID, Action: "state"})
+		}
+	}
+
+	// Only the guess that ended the game gets here with finished set, so this runs once per game
+	if finished != nil {
+		finishGame(finished)
+	}
+	return nil
+}
+
+// Helper: Register a guess and recount the dictionary words still fitting the board;
+// caller holds the game's lock
+func applyGuess(game *models.Game, letter string) {
+	logic.RegisterGuess(game, letter)
+	game.Candidates = logic.CountCandidates(game)
+}
+
+// Helper: A copy of game that can be read after its lock is released
+func copyGame(game *models.Game) *models.Game {
+	c := *game
+	c.GuessedLetters = make(map[string]bool, len(game.GuessedLetters))
+	for letter, guessed := range game.GuessedLetters {
+		c.GuessedLetters[letter] = guessed
+	}
+	c.GuessHistory = append([]string(nil), game.GuessHistory...)
+	return &c
+}
+
+// Helper: Everything a finished game triggers, given a copy of it taken when it ended.
+// Counts it on both players' leaderboard rows (games played, hints), saves it to the games
+// table for /history (draws included), moves its players' ratings, and tells them about
+// any achievements it unlocked.
+func finishGame(game *models.Game) {
+	recordGameStats(game)
+	gameRowID := saveFinishedGame(game)
+	updateRatings(game, gameRowID, time.Now())
+	notifyAchievements(game, checkAchievements(game, gameRowID))
+}
+
+// Helper: The secret word as one viewer may see it: empty while the game is running,
+// except for the host of a custom-word game (who typed it in). Everything sent to a
+// browser or API client must get the word from here, never from game.Word directly.
+func visibleWord(game *models.Game, seat string) string {
+	if game.Status == "finished" || (game.CustomWord && seat == "1") {
+		return game.Word
+	}
+	return ""
+}
+
+// Helper: build the per-game, per viewer state as a map (seat "" = spectator).
+// This is the projection sent over the WebSocket and used by /state; see visibleWord.
+func buildGameState(game *models.Game, seat string) map[string]interface{} {
 	correct, wrong := []string{}, []string{}
 	for l := range game.GuessedLetters {
-		if strings.Contains(game.Word, l) {
+		if logic.InWord(game, l) {
 			correct = append(correct, l)
 		} else {
 			wrong = append(wrong, l)
@@ -292,39 +733,58 @@ func buildGameState(game *models.Game, role string) map[string]interface{} {
 	if len(game.GuessHistory) > 0 {
 		lastGuess = game.GuessHistory[len(game.GuessHistory)-1]
 	}
+	turn := game.Player1
+	if game.PlayerTurn == 2 {
+		turn = game.Player2
+	}
 	return map[string]interface{}{
 		"DisplayWord":  game.DisplayWord,
 		"Remaining":    game.MaxIncorrectGuesses - game.IncorrectGuesses,
 		"Correct":      strings.Join(correct, ", "),
 		"Wrong":        strings.Join(wrong, ", "),
+		"Guesses":      strings.Join(game.GuessHistory, " "),
+		"Turn":         turn,
+		"Status":       game.Status,
 		"GameOver":     game.Status == "finished",
 		"Winner":       game.Winner,
-		"Word":         game.Word,
-		"IsPlayerTurn": role == fmt.Sprintf("%d", game.PlayerTurn),
+		"Word":         visibleWord(game, seat),
+		"IsPlayerTurn": seat == strconv.Itoa(game.PlayerTurn),
 		"LastGuess":    lastGuess,
+		"Candidates":   game.Candidates, // analytics: dictionary words still possible (0 = not counted)
 	}
 }
 
-// Update (or insert) leaderboard for player; increments win, tracks best score for user (fewest incorrect guesses)
-func updateLeaderboard(winner string, incorrectGuesses int) {
-	var userID int
-	err := db.DB.QueryRow("SELECT id FROM users WHERE username = ?", winner).Scan(&userID)
-	if err != nil {
-		// User not found, log but continue
-		fmt.Println("Leaderboard update error: could not find user", winner)
-		return
-	}
-	// Uses upsert: on conflict, increase wins, update best_score if improved
-	_, err = db.DB.Exec(`
-        INSERT INTO leaderboard (player, wins, best_score)
-        VALUES (?, 1, ?)
-        ON CONFLICT(player) DO UPDATE SET
-            wins = wins + 1,
-            best_score = CASE WHEN best_score IS NULL OR ? < best_score THEN ? ELSE best_score END
-    `, userID, incorrectGuesses, incorrectGuesses, incorrectGuesses)
-	if err != nil {
-		fmt.Println("Leaderboard update error:", err)
-	}
+// Returned by takeHint when the word setter or a spectator asks for a hint, or the game is over
+var (
+	errSetterHint    = errors.New("the word setter can't use hints")
+	errSpectatorHint = errors.New("only players can use hints")
+	errHintGameOver  = errors.New("the game is over")
+)
+
+// Helper: Give username the game's hint (the same one again if it was already used)
+func takeHint(gameID, username string) (string, error) {
+	var hint string
+	err := games.Update(gameID, func(game *models.Game) error {
+		if game.CustomWord && seatOf(game, username) == "1" {
+			// The host already knows the word; hints are for the guesser only
+			return errSetterHint
+		}
+		if seatOf(game, username) == "" {
+			return errSpectatorHint
+		}
+		if game.Status == "finished" {
+			return errHintGameOver
+		}
+		// Already has hint: GetHint just returns it again (and it stays credited to whoever took it first)
+		firstUse := !game.HasUsedHint
+		var err error
+		hint, err = logic.GetHint(game)
+		if err == nil && firstUse {
+			game.HintUsedBy = username
+		}
+		return err
+	})
+	return hint, err
 }
 
 // Give a hint to the current player, if none used yet, using logic.GetHint
@@ -334,22 +794,21 @@ func HintHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing game ID", http.StatusBadRequest)
 		return
 	}
-	game, ok := games[cookie.Value]
-	if !ok {
+	hint, err := takeHint(cookie.Value, requestUser(r))
+	switch {
+	case err == store.ErrNotFound:
 		http.Error(w, "Game not found", http.StatusNotFound)
-		return
-	}
-	if game.HasUsedHint {
-		// Already has hint, just return it again
-		fmt.Fprint(w, game.HintText)
-		return
-	}
-	hint, err := logic.GetHint(game)
-	if err != nil {
+	case err == errSetterHint:
+		http.Error(w, "The word setter can't use hints", http.StatusForbidden)
+	case err == errSpectatorHint:
+		http.Error(w, "Only players can use hints", http.StatusForbidden)
+	case err == errHintGameOver:
+		http.Error(w, "The game is over, so no more hints", http.StatusConflict)
+	case err != nil:
 		http.Error(w, "Hint unavailable", http.StatusInternalServerError)
-		return
+	default:
+		fmt.Fprint(w, hint)
 	}
-	fmt.Fprint(w, hint)
 }
 
 // State endpoint: Used for HTMX/live updates, returns slice of game state for the current session
@@ -360,58 +819,42 @@ func StateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	gameID := cookie.Value
-	game, ok := games[gameID]
-	if !ok {
+
+	user := requestUser(r)
+
+	// Copy what we need while holding the game's lock; respond after releasing it
+	var data map[string]interface{}
+	var status string
+	var hasPlayer2 bool
+	err = games.View(gameID, func(game *models.Game) error {
+		status = game.Status
+		hasPlayer2 = game.Player2 != ""
+
+		// Start from the viewer's projection (no secret word mid-game), plus the fixed details
+		data = buildGameState(game, seatOf(game, user))
+		data["GameID"] = game.ID
+		data["CustomWord"] = game.CustomWord
+		data["Player1"] = game.Player1
+		data["Player2"] = game.Player2
+		data["Category"] = game.Category
+		data["Difficulty"] = game.Difficulty
+		data["HasUsedHint"] = game.HasUsedHint
+		data["HintText"] = game.HintText
+		return nil
+	})
+	if err != nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
 	}
-	if game.Player2 != "" && game.Status == "in_progress" {
+	if hasPlayer2 && status == "in_progress" {
 		// If both players ready and in progress, tell HTMX client to redirect to main gameplay
 		w.Header().Set("HX-Redirect", "/gameplay")
 		return
 	}
 
-	// Gather correct and wrong guesses
-	var correct, wrong []string
-	for letter, guessed := range game.GuessedLetters {
-		if guessed {
-			if strings.Contains(game.Word, letter) {
-				correct = append(correct, letter)
-			} else {
-				wrong = append(wrong, letter)
-			}
-		}
-	}
-
-	// Get player role from cookie if present
-	role := ""
-	roleCookie, err := r.Cookie("role")
-	if err == nil {
-		role = roleCookie.Value
-	}
-	isPlayerTurn := role == fmt.Sprintf("%d", game.PlayerTurn)
-
-	// Build state dictionary for template/partial rendering
-	data := map[string]interface{}{
-		"GameID":       game.ID,
-		"Player1":      game.Player1,
-		"Player2":      game.Player2,
-		"DisplayWord":  game.DisplayWord,
-		"Remaining":    game.MaxIncorrectGuesses - game.IncorrectGuesses,
-		"Correct":      strings.Join(correct, ", "),
-		"Wrong":        strings.Join(wrong, ", "),
-		"HasUsedHint":  game.HasUsedHint,
-		"HintText":     game.HintText,
-		"Status":       game.Status,
-		"GameOver":     game.Status == "finished",
-		"Winner":       game.Winner,
-		"Word":         game.Word,
-		"IsPlayerTurn": isPlayerTurn,
-	}
-
 	// If still waiting, render waiting or, if player2 just joined, send client redirect to /gameplay
-	if game.Status == "waiting" {
-		if game.Player2 != "" {
+	if status == "waiting" {
+		if hasPlayer2 {
 			fmt.Fprint(w, `<script>window.location.replace("/gameplay");</script>`)
 		} else {
 			utils.RenderPartial(w, r, "waiting.html", data)
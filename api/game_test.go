@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wordgame/db"
+	"wordgame/logic"
+	"wordgame/models"
+	"wordgame/session"
+)
+
+// The handler tests share one throwaway database, and run from the repo root so the
+// templates/ directory is found (as it is for the server).
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "handlers-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("DB_PATH", filepath.Join(dir, "test.db"))
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	db.InitDB()
+	code := m.Run()
+	db.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// Helper: Make sure a user exists (games are saved and rated by user ID once they finish)
+func testUser(t *testing.T, username string) int {
+	t.Helper()
+	if _, err := db.DB.Exec("INSERT OR IGNORE INTO users (username, password_hash) VALUES (?, 'x')", username); err != nil {
+		t.Fatalf("creating user %s: %v", username, err)
+	}
+	id, err := lookupUserID(username)
+	if err != nil {
+		t.Fatalf("looking up user %s: %v", username, err)
+	}
+	return id
+}
+
+// Helper: A new user named base plus a number, for tests that count a player's saved games
+// (the database is shared, including across go test -count runs)
+func freshUser(t *testing.T, base string) string {
+	t.Helper()
+	username := fmt.Sprintf("%s_%d", base, freshUsers.Add(1))
+	testUser(t, username)
+	return username
+}
+
+var freshUsers atomic.Int64
+
+// Helper: Register a game in progress between player1 and player2 with the given word
+func testGame(t *testing.T, word, player1, player2 string) *models.Game {
+	t.Helper()
+	testUser(t, player1)
+	if player2 != logic.AIPlayerName {
+		testUser(t, player2)
+	}
+	game := &models.Game{
+		Word:                word,
+		Language:            "en",
+		GuessedLetters:      make(map[string]bool),
+		MaxIncorrectGuesses: 26,
+		PlayerTurn:          1,
+		Player1:             player1,
+		Player2:             player2,
+		Status:              "in_progress",
+		StartedAt:           time.Now(),
+	}
+	game.DisplayWord = logic.MaskWord(game)
+	if err := registerGame(game); err != nil {
+		t.Fatalf("registerGame: %v", err)
+	}
+	return game
+}
+
+// Helper: Check the counters agree with the guesses (every guess once, misses = wrong guesses)
+func checkGuessCounts(t *testing.T, game *models.Game) {
+	t.Helper()
+	if len(game.GuessHistory) != len(game.GuessedLetters) {
+		t.Errorf("%d guesses in the history but %d guessed letters", len(game.GuessHistory), len(game.GuessedLetters))
+	}
+	misses := 0
+	for _, letter := range game.GuessHistory {
+		if !game.GuessedLetters[letter] {
+			t.Errorf("guess %q is in the history but not in GuessedLetters", letter)
+		}
+		if !logic.InWord(game, letter) {
+			misses++
+		}
+	}
+	if game.IncorrectGuesses != misses {
+		t.Errorf("IncorrectGuesses = %d, but the history has %d misses", game.IncorrectGuesses, misses)
+	}
+}
+
+// Both players hammer playGuess with every letter at once while others read the game.
+// Run with -race: each accepted guess must be in the history exactly once, in turn order.
+func TestPlayGuessConcurrent(t *testing.T) {
+	game := testGame(t, "quizzical", "race_p1", "race_p2")
+
+	var mu sync.Mutex
+	acceptedBy := make(map[string]string) // letter -> player whose guess was accepted
+	var wg sync.WaitGroup
+	for _, player := range []string{"race_p1", "race_p2"} {
+		for _, l := range "abcdefghijklmnopqrstuvwxyz" {
+			wg.Add(1)
+			go func(player, letter string) {
+				defer wg.Done()
+				for {
+					err := playGuess(game.ID, player, letter)
+					if err == nil {
+						mu.Lock()
+						if prev, ok := acceptedBy[letter]; ok {
+							t.Errorf("letter %q accepted twice (%s and %s)", letter, prev, player)
+						}
+						acceptedBy[letter] = player
+						mu.Unlock()
+						return
+					}
+					if err != errNotYourTurn {
+						return // already guessed or the game is over
+					}
+				}
+			}(player, string(l))
+		}
+	}
+	// Spectators reading the state meanwhile
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				games.View(game.ID, func(game *models.Game) error {
+					buildGameState(game, "")
+					return nil
+				})
+			}
+		}()
+	}
+	// Stop the readers once the game is over
+	for {
+		finished := false
+		games.View(game.ID, func(game *models.Game) error {
+			finished = game.Status == "finished"
+			return nil
+		})
+		if finished {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+
+	games.View(game.ID, func(game *models.Game) error {
+		checkGuessCounts(t, game)
+		if len(game.GuessHistory) != len(acceptedBy) {
+			t.Errorf("%d guesses accepted but %d in the history (a guess was lost)", len(acceptedBy), len(game.GuessHistory))
+		}
+		// Accepted guesses alternate between the seats, starting with player 1
+		for i, letter := range game.GuessHistory {
+			want := "race_p1"
+			if i%2 == 1 {
+				want = "race_p2"
+			}
+			if acceptedBy[letter] != want {
+				t.Errorf("guess %d (%q) was %s's, want %s's", i+1, letter, acceptedBy[letter], want)
+			}
+		}
+		if !logic.IsSolved(game) {
+			t.Errorf("game finished without the word being solved: %s", game.DisplayWord)
+		}
+		return nil
+	})
+}
+
+// Against the computer every accepted guess is answered by the AI before playGuess returns
+// (its move is worked out unlocked, but the human can't guess until it's applied).
+func TestPlayGuessConcurrentVsAI(t *testing.T) {
+	game := testGame(t, "rhythm", "race_solo", logic.AIPlayerName)
+	games.Update(game.ID, func(game *models.Game) error {
+		game.AIStrategy = "frequency"
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for _, l := range "abcdefghijklmnopqrstuvwxyz" {
+		wg.Add(1)
+		go func(letter string) {
+			defer wg.Done()
+			playGuess(game.ID, "race_solo", letter)
+		}(string(l))
+	}
+	wg.Wait()
+
+	games.View(game.ID, func(game *models.Game) error {
+		checkGuessCounts(t, game)
+		if game.Status != "finished" && game.PlayerTurn != 1 {
+			t.Errorf("it's the computer's turn after the human's guesses returned")
+		}
+		return nil
+	})
+}
+
+// Hints are refused once the game is over, on the page and in the JSON API alike.
+func TestHintAfterGameOver(t *testing.T) {
+	game := testGame(t, "ox", "hint_p1", "hint_p2")
+	for _, guess := range []struct{ player, letter string }{{"hint_p1", "o"}, {"hint_p2", "x"}} {
+		if err := playGuess(game.ID, guess.player, guess.letter); err != nil {
+			t.Fatalf("playGuess(%s, %s): %v", guess.player, guess.letter, err)
+		}
+	}
+	if _, err := takeHint(game.ID, "hint_p1"); err != errHintGameOver {
+		t.Fatalf("takeHint after the game ended = %v, want errHintGameOver", err)
+	}
+
+	page := serveAs(t, "hint_p1", http.HandlerFunc(HintHandler), gameRequest("GET", "/hint", game.ID))
+	if page.Code != http.StatusConflict {
+		t.Errorf("/hint after the game ended: status %d, want %d", page.Code, http.StatusConflict)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/games/{id}/hint", APIHintHandler)
+	api := serveAs(t, "hint_p1", mux, httptest.NewRequest("POST", "/api/v1/games/"+game.ID+"/hint", nil))
+	if api.Code != http.StatusConflict || !strings.Contains(api.Body.String(), errHintGameOver.Error()) {
+		t.Errorf("/api/v1/games/{id}/hint after the game ended: %d %s, want %d %q", api.Code, api.Body.String(), http.StatusConflict, errHintGameOver)
+	}
+}
+
+// Plain games.Update calls from many goroutines never lose a change (run with -race).
+func TestGamesUpdateConcurrent(t *testing.T) {
+	game := testGame(t, "lock", "race_p1", "race_p2")
+	const workers, perWorker = 16, 25
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				games.Update(game.ID, func(game *models.Game) error {
+					game.IncorrectGuesses++
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	games.View(game.ID, func(game *models.Game) error {
+		if game.IncorrectGuesses != workers*perWorker {
+			t.Errorf("IncorrectGuesses = %d, want %d", game.IncorrectGuesses, workers*perWorker)
+		}
+		return nil
+	})
+}
+
+// -------- SECRET WORD PROJECTION --------
+
+// Helper: Run handler as username (logged in through a real session cookie; "" = not logged in)
+func serveAs(t *testing.T, username string, handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	if username != "" {
+		login := httptest.NewRecorder()
+		if err := session.Create(login, r, testUser(t, username)); err != nil {
+			t.Fatalf("session for %s: %v", username, err)
+		}
+		for _, c := range login.Result().Cookies() {
+			r.AddCookie(c)
+		}
+	}
+	w := httptest.NewRecorder()
+	session.Middleware(handler).ServeHTTP(w, r)
+	return w
+}
+
+// Helper: A request carrying the game cookie, as the browser sends it
+func gameRequest(method, target, gameID string) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	r.AddCookie(&http.Cookie{Name: "game_id", Value: gameID})
+	return r
+}
+
+// Helper: Fail if the secret word shows up anywhere in a payload (JSON-encoded) or page
+func assertHidden(t *testing.T, what, word string, payload interface{}) {
+	t.Helper()
+	text, ok := payload.(string)
+	if !ok {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("%s: %v", what, err)
+		}
+		text = string(data)
+	}
+	if strings.Contains(strings.ToLower(text), word) {
+		t.Errorf("%s contains the secret word %q while the game is in progress", what, word)
+	}
+}
+
+// Every payload a player, spectator or passer-by can get mid-game leaves the word out.
+func TestInProgressPayloadsHideWord(t *testing.T) {
+	const word = "quizzical"
+	game := testGame(t, word, "proj_p1", "proj_p2")
+	if err := playGuess(game.ID, "proj_p1", "z"); err != nil {
+		t.Fatalf("playGuess: %v", err)
+	}
+	viewers := []string{"proj_p1", "proj_p2", "proj_watcher", ""}
+
+	games.View(game.ID, func(game *models.Game) error {
+		for _, seat := range []string{"", "1", "2"} {
+			assertHidden(t, "buildGameState(seat "+strconv.Quote(seat)+")", word, buildGameState(game, seat))
+		}
+		for _, user := range viewers {
+			assertHidden(t, "apiState("+user+")", word, apiState(game, user))
+		}
+		return nil
+	})
+
+	for _, user := range viewers {
+		// gameplayData (what the game page template gets)
+		var data map[string]interface{}
+		capture := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			games.View(game.ID, func(game *models.Game) error {
+				data = gameplayData(r, game)
+				return nil
+			})
+		})
+		serveAs(t, user, capture, gameRequest("GET", "/gameplay", game.ID))
+		assertHidden(t, "gameplayData("+user+")", word, data)
+
+		// The rendered pages and the JSON API
+		page := serveAs(t, user, http.HandlerFunc(GameplayHandler), gameRequest("GET", "/gameplay", game.ID))
+		if page.Code != http.StatusOK || strings.Contains(page.Body.String(), "Template error") {
+			t.Errorf("/gameplay as %s: status %d, want the game page", user, page.Code)
+		}
+		assertHidden(t, "/gameplay as "+user, word, page.Body.String())
+		state := serveAs(t, user, http.HandlerFunc(StateHandler), gameRequest("GET", "/state", game.ID))
+		assertHidden(t, "/state as "+user, word, state.Body.String())
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /api/v1/games/{id}", APIGameStateHandler)
+		api := serveAs(t, user, mux, httptest.NewRequest("GET", "/api/v1/games/"+game.ID, nil))
+		assertHidden(t, "/api/v1/games/{id} as "+user, word, api.Body.String())
+	}
+}
+
+// A game still waiting for player 2 renders the waiting room from /state: no word there either.
+func TestWaitingStateHidesWord(t *testing.T) {
+	const word = "jukebox"
+	game := testGame(t, word, "proj_host", "")
+	games.Update(game.ID, func(game *models.Game) error {
+		game.Status = "waiting"
+		return nil
+	})
+	state := serveAs(t, "proj_host", http.HandlerFunc(StateHandler), gameRequest("GET", "/state", game.ID))
+	assertHidden(t, "/state in the waiting room", word, state.Body.String())
+}
+
+// The word is only shown early to the host of a custom-word game, and to everyone once it's over.
+func TestVisibleWord(t *testing.T) {
+	game := &models.Game{Word: "fjord", Status: "in_progress"}
+	for _, seat := range []string{"", "1", "2"} {
+		if got := visibleWord(game, seat); got != "" {
+			t.Errorf("in progress, seat %q: visibleWord = %q, want hidden", seat, got)
+		}
+	}
+
+	game.CustomWord = true
+	if got := visibleWord(game, "1"); got != "fjord" {
+		t.Errorf("custom-word host: visibleWord = %q, want the word", got)
+	}
+	if got := visibleWord(game, "2"); got != "" {
+		t.Errorf("custom-word guesser: visibleWord = %q, want hidden", got)
+	}
+
+	game.Status = "finished"
+	for _, seat := range []string{"", "1", "2"} {
+		if got := visibleWord(game, seat); got != "fjord" {
+			t.Errorf("finished, seat %q: visibleWord = %q, want the word", seat, got)
+		}
+	}
+}
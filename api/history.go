@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"wordgame/db"
+	"wordgame/logic"
+	"wordgame/models"
+	"wordgame/utils"
+)
+
+// Page size limits for /history
+const (
+	historyPerPage    = 20
+	historyMaxPerPage = 100
+)
+
+// One finished game as listed on /history (HTML and JSON)
+type HistoryEntry struct {
+	ID              int       `json:"id"`
+	Code            string    `json:"code"`    // 4-letter game ID used while playing
+	Mode            string    `json:"mode"`    // "versus", "ai", "custom"
+	Word            string    `json:"word"`    // secret word (game is over, so no spoiler)
+	Player1         string    `json:"player1"` // host / first player
+	Player2         string    `json:"player2"` // opponent, "Computer" for AI games
+	Winner          string    `json:"winner"`  // username, "Draw", or "" for none
+	Result          string    `json:"result"`  // from the listed user's side: "won", "lost", "draw"
+	Guesses         []string  `json:"guesses"` // letters in the order they were guessed
+	Misses          int       `json:"misses"`
+	MaxMisses       int       `json:"max_misses"`
+	HintUsed        bool      `json:"hint_used"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationSeconds int       `json:"duration_seconds"`
+}
+
+// Duration formats how long the game took, e.g. "3m12s" (used by history.html)
+func (e HistoryEntry) Duration() string {
+	return (time.Duration(e.DurationSeconds) * time.Second).String()
+}
+
+// Helper: Classify a game for storage/stats ("ai", "custom" or "versus")
+func gameMode(game *models.Game) string {
+	switch {
+	case game.Player2 == logic.AIPlayerName:
+		return "ai"
+	case game.CustomWord:
+		return "custom"
+	default:
+		return "versus"
+	}
+}
+
+// Persist a finished game to the games table (status "won" = the word was solved) and return
+// its row ID (0 if it couldn't be saved). Called once, when the guess that ends the game has been registered.
+func saveFinishedGame(game *models.Game) int64 {
+	player1ID, err := lookupUserID(game.Player1)
+	if err != nil {
+		fmt.Println("Game history error: could not find user", game.Player1)
+		return 0
+	}
+	// Player 2 may be the AI (no user row): store NULL and keep the display name
+	var player2ID sql.NullInt64
+	if gameMode(game) != "ai" {
+		if id, err := lookupUserID(game.Player2); err == nil {
+			player2ID = sql.NullInt64{Int64: int64(id), Valid: true}
+		}
+	}
+
+	status := "lost"
+	if logic.IsSolved(game) {
+		status = "won"
+	}
+
+	res, err := db.DB.Exec(`
+        INSERT INTO games (
+            word, guessed_letters, remaining_attempts, player_id, status, created_at, finished_at,
+            game_code, player2_id, player2_name, winner, mode, incorrect_guesses, max_incorrect,
+            hint_used, category, language, difficulty, hint_taker, accent_insensitive
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+		game.Word, strings.Join(game.GuessHistory, ","), game.MaxIncorrectGuesses-game.IncorrectGuesses,
+		player1ID, status, game.StartedAt.UTC(), time.Now().UTC(),
+		game.ID, player2ID, game.Player2, game.Winner, gameMode(game), game.IncorrectGuesses,
+		game.MaxIncorrectGuesses, game.HasUsedHint, game.Category, game.Language, game.Difficulty,
+		game.HintUsedBy, game.AccentInsensitive,
+	)
+	if err != nil {
+		fmt.Println("Game history error:", err)
+		return 0
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
+
+// Helper: Rebuild the parts of a saved game that guess checking needs (logic.InWord, missedLetters)
+// from its games row; players, winner and the rest are up to the caller.
+func storedGame(mode, word, guesses, language string, accentInsensitive bool) *models.Game {
+	game := &models.Game{
+		Word:              word,
+		Language:          language,
+		AccentInsensitive: accentInsensitive,
+		CustomWord:        mode == "custom",
+		GuessedLetters:    make(map[string]bool),
+	}
+	if guesses != "" {
+		game.GuessHistory = strings.Split(guesses, ",")
+	}
+	for _, letter := range game.GuessHistory {
+		game.GuessedLetters[letter] = true
+	}
+	return game
+}
+
+// Helper: Load one page of a user's finished games, newest first, plus the total count
+func loadHistory(userID, page, perPage int) ([]HistoryEntry, int, error) {
+	var total int
+	err := db.DB.QueryRow(`
+        SELECT COUNT(*) FROM games
+        WHERE (player_id = ? OR player2_id = ?) AND finished_at IS NOT NULL
+    `, userID, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.DB.Query(`
+        SELECT g.id, g.game_code, g.mode, g.word, u.username, g.player2_name, g.winner,
+               g.guessed_letters, g.incorrect_guesses, g.max_incorrect, g.hint_used,
+               g.created_at, g.finished_at
+        FROM games g
+        JOIN users u ON g.player_id = u.id
+        WHERE (g.player_id = ? OR g.player2_id = ?) AND g.finished_at IS NOT NULL
+        ORDER BY g.finished_at DESC, g.id DESC
+        LIMIT ? OFFSET ?
+    `, userID, userID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []HistoryEntry{}
+	for rows.Next() {
+		var e HistoryEntry
+		var guesses string
+		if err := rows.Scan(&e.ID, &e.Code, &e.Mode, &e.Word, &e.Player1, &e.Player2, &e.Winner,
+			&guesses, &e.Misses, &e.MaxMisses, &e.HintUsed, &e.StartedAt, &e.FinishedAt); err != nil {
+			return nil, 0, err
+		}
+		e.Guesses = []string{}
+		if guesses != "" {
+			e.Guesses = strings.Split(guesses, ",")
+		}
+		e.DurationSeconds = int(e.FinishedAt.Sub(e.StartedAt).Seconds())
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// Helper: Result of a stored game from one player's point of view
+func resultFor(e HistoryEntry, username string) string {
+	switch e.Winner {
+	case username:
+		return "won"
+	case "Draw":
+		return "draw"
+	default:
+		return "lost"
+	}
+}
+
+// GET /history: a user's finished games, newest first.
+// Query params: user (default: you), page (1-based), per_page (max 100), format=json for the JSON variant.
+func HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	viewer, ok := getUser(w, r)
+	if !ok {
+		return
+	}
+	username := r.URL.Query().Get("user")
+	if username == "" {
+		username = viewer
+	}
+	page := parseIntWithDefault(r.URL.Query().Get("page"), 1)
+	perPage := parseIntWithDefault(r.URL.Query().Get("per_page"), historyPerPage)
+	if perPage > historyMaxPerPage {
+		perPage = historyMaxPerPage
+	}
+	asJSON := r.URL.Query().Get("format") == "json"
+
+	userID, err := lookupUserID(username)
+	if err != nil {
+		if asJSON {
+			writeJSONError(w, http.StatusNotFound, "user not found")
+		} else {
+			http.Error(w, "User not found", http.StatusNotFound)
+		}
+		return
+	}
+
+	entries, total, err := loadHistory(userID, page, perPage)
+	if err != nil {
+		if asJSON {
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+		} else {
+			http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	for i := range entries {
+		entries[i].Result = resultFor(entries[i], username)
+	}
+	totalPages := (total + perPage - 1) / perPage
+
+	if asJSON {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user":        username,
+			"page":        page,
+			"per_page":    perPage,
+			"total":       total,
+			"total_pages": totalPages,
+			"games":       entries,
+		})
+		return
+	}
+
+	data := map[string]interface{}{
+		"Player":     username,
+		"Entries":    entries,
+		"Page":       page,
+		"TotalPages": totalPages,
+		"Total":      total,
+	}
+	if page > 1 {
+		data["PrevPage"] = page - 1
+	}
+	if page < totalPages {
+		data["NextPage"] = page + 1
+	}
+	utils.RenderPage(w, r, "history.html", data)
+}
+
+// Helper: Write v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Println("JSON encode error:", err)
+	}
+}
+
+// Helper: Write a JSON error body: {"error": "..."}
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
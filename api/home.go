@@ -3,21 +3,27 @@ package handlers
 import (
 	"net/http"
 	"net/url"
+	"wordgame/logic"
+	"wordgame/session"
 	"wordgame/utils"
+	"wordgame/words"
 )
 
 // WelcomeHandler displays the welcome page, with user greeting and any error messages.
 func WelcomeHandler(w http.ResponseWriter, r *http.Request) {
 	user := "" // Default: empty user
 
-	// Try to read the "user" cookie, which identifies logged-in users.
-	if c, err := r.Cookie("user"); err == nil {
-		user = c.Value // If present, get username
+	// Logged-in users are identified by their session (see session.Middleware).
+	if u, ok := session.FromContext(r.Context()); ok {
+		user = u.Username
 	}
 
-	// Prepare data for the template, always including user (may be empty).
+	// Prepare data for the template, always including user (may be empty) and the game setting choices.
 	data := map[string]interface{}{
-		"User": user,
+		"User":       user,
+		"Categories": words.Categories(),
+		"Languages":  words.Languages(),
+		"Strategies": logic.AIStrategies(),
 	}
 
 	// See if an "error" cookie is set (usually after a redirect), and pass it to the template.
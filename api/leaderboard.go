@@ -1,33 +1,191 @@
 package handlers
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 	"wordgame/db"
+	"wordgame/logic"
+	"wordgame/models"
 	"wordgame/utils"
 )
 
+// -------- BOARDS --------
+//
+// /leaderboard shows one board at a time, chosen with query parameters:
+//
+//	board=players|ai|bots    ratings against people (default), against the computer, or bot accounts
+//	window=all|month|week    all time (ranked by rating) or the last 30 / 7 days (ranked by rating gained)
+//	page=N, per_page=N       pagination (10 players per page by default)
+//	me=1                     jump to the page with the logged-in user on it
+//	format=json              the same data as JSON
+//
+// Every board is computed from rating_history (one row per player per rated game), so a window's
+// results, misses and best score only count the games finished inside it.
+
+// Page size limits for /leaderboard
+const (
+	leaderboardPerPage    = 10
+	leaderboardMaxPerPage = 100
+)
+
+// One of the leaderboard's boards (?board=)
+type leaderboardBoard struct {
+	Key   string
+	Label string
+	pool  string // rating pool: poolVersus or poolAI
+	bots  bool   // bot accounts are ranked apart from people
+}
+
+var leaderboardBoards = []leaderboardBoard{
+	{Key: "players", Label: "Players", pool: poolVersus},
+	{Key: "ai", Label: "vs. Computer", pool: poolAI},
+	{Key: "bots", Label: "Bots", pool: poolVersus, bots: true},
+}
+
+// One of the leaderboard's time windows (?window=)
+type leaderboardWindow struct {
+	Key   string
+	Label string
+	Days  int // 0 = all time
+}
+
+var leaderboardWindows = []leaderboardWindow{
+	{Key: "all", Label: "All Time"},
+	{Key: "month", Label: "Last 30 Days", Days: 30},
+	{Key: "week", Label: "Last 7 Days", Days: 7},
+}
+
+// Which board, window and page a /leaderboard request is for
+type leaderboardQuery struct {
+	Board   leaderboardBoard
+	Window  leaderboardWindow
+	Page    int
+	PerPage int
+}
+
 // Data structure for holding a leaderboard entry as displayed in the UI
 type LeaderboardEntry struct {
+	Rank      int    // Position on the board (1 = top)
 	Player    string // Player username
-	Wins      int    // Total wins for the player
+	Rating    int    // Current Elo rating in the board's pool
+	Change    int    // Rating gained (or lost) in the board's time window
+	Games     int    // Rated games played in the pool during the window
+	Wins      int    // Wins in those games
+	Losses    int    // Losses
+	Draws     int    // Draws
+	Misses    int    // Wrong letters the player guessed in those games
 	BestScore string // Best score ("N/A" if no games won, otherwise a number as string)
+	Best      int    // Best score as a number (only meaningful if HasBest)
+	HasBest   bool   // Whether the player won a game by guessing in the window
+	WinRate   string // Share of games won, e.g. "64%" ("N/A" before the first game)
+	AvgMisses string // Wrong letters per game, e.g. "2.3" ("N/A" before the first game)
 }
 
-// Handler to display the leaderboard page (top 10 players by win count, then by best score)
-func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
-	// Query top 10 leaderboard records, joining user id to username, sorted by most wins, then lowest best_score
+// ChangeText formats Change with its sign, e.g. "+32" (used by leaderboard.html)
+func (e LeaderboardEntry) ChangeText() string {
+	return fmt.Sprintf("%+d", e.Change)
+}
+
+// Helper: Read board, window and page from the query string (unknown board or window = error)
+func parseLeaderboardQuery(r *http.Request) (leaderboardQuery, error) {
+	query := r.URL.Query()
+	q := leaderboardQuery{
+		Board:   leaderboardBoards[0],
+		Window:  leaderboardWindows[0],
+		Page:    parseIntWithDefault(query.Get("page"), 1),
+		PerPage: min(parseIntWithDefault(query.Get("per_page"), leaderboardPerPage), leaderboardMaxPerPage),
+	}
+	if key := query.Get("board"); key != "" {
+		found := false
+		for _, b := range leaderboardBoards {
+			if b.Key == key {
+				q.Board, found = b, true
+			}
+		}
+		if !found {
+			return q, fmt.Errorf("unknown board %q", key)
+		}
+	}
+	if key := query.Get("window"); key != "" {
+		found := false
+		for _, win := range leaderboardWindows {
+			if win.Key == key {
+				q.Window, found = win, true
+			}
+		}
+		if !found {
+			return q, fmt.Errorf("unknown window %q", key)
+		}
+	}
+	return q, nil
+}
+
+// URL of another page of the same board and window
+func (q leaderboardQuery) URL(page int) string {
+	v := url.Values{}
+	v.Set("board", q.Board.Key)
+	v.Set("window", q.Window.Key)
+	v.Set("page", strconv.Itoa(page))
+	if q.PerPage != leaderboardPerPage {
+		v.Set("per_page", strconv.Itoa(q.PerPage))
+	}
+	return "/leaderboard?" + v.Encode()
+}
+
+// Helper: Oldest rating_history.created_at counted on the board ("" = all time)
+func (q leaderboardQuery) since() string {
+	if q.Window.Days == 0 {
+		return ""
+	}
+	return time.Now().UTC().AddDate(0, 0, -q.Window.Days).Format(historyTimeFormat)
+}
+
+// Helper: The ranking order: all time by current rating; a window by rating gained, then wins
+func (q leaderboardQuery) order() string {
+	if q.Window.Days == 0 {
+		return "rating DESC, games DESC, username ASC"
+	}
+	return "gained DESC, wins DESC, rating DESC, username ASC"
+}
+
+// The rows behind every board: one per player with a rated game in the pool and window.
+// Best score is the fewest misses in a game the player won by guessing (a host whose word
+// survived didn't guess at all).
+const leaderboardRows = `
+        SELECT u.username AS username, r.rating AS rating, COUNT(*) AS games,
+               SUM(h.result = 'win') AS wins, SUM(h.result = 'loss') AS losses, SUM(h.result = 'draw') AS draws,
+               COALESCE(SUM(h.misses), 0) AS misses, SUM(h.new_rating - h.old_rating) AS gained,
+               MIN(CASE WHEN h.result = 'win' AND NOT (g.mode = 'custom' AND g.player_id = h.user_id)
+                        THEN g.incorrect_guesses END) AS best
+        FROM rating_history h
+        JOIN users u ON h.user_id = u.id
+        JOIN ratings r ON r.user_id = h.user_id AND r.pool = h.pool
+        LEFT JOIN games g ON g.id = h.game_id
+        WHERE h.pool = ? AND u.is_bot = ? AND h.created_at >= ?
+        GROUP BY h.user_id`
+
+// Helper: Load one page of a board, plus how many players are on it in total
+func loadLeaderboard(q leaderboardQuery) ([]LeaderboardEntry, int, error) {
+	args := []interface{}{q.Board.pool, q.Board.bots, q.since()}
+
+	var total int
+	if err := db.DB.QueryRow("SELECT COUNT(*) FROM ("+leaderboardRows+")", args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (q.Page - 1) * q.PerPage
 	rows, err := db.DB.Query(`
-        SELECT u.username, l.wins, l.best_score
-        FROM leaderboard l
-        JOIN users u ON l.player = u.id
-        ORDER BY l.wins DESC, l.best_score ASC
-        LIMIT 10
-    `)
+        SELECT username, rating, games, wins, losses, draws, misses, gained, best
+        FROM (`+leaderboardRows+`)
+        ORDER BY `+q.order()+`
+        LIMIT ? OFFSET ?
+    `, append(args, q.PerPage, offset)...)
 	if err != nil {
-		// On DB query failure, return an HTTP 500 and the DB error message
-		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, 0, err
 	}
 	defer rows.Close() // Ensure DB rows are closed to avoid leaks
 
@@ -35,28 +193,274 @@ func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Read each row from the database result
 	for rows.Next() {
-		var username string
-		var wins, best int
-		// Scan row into variables; best is best_score (could be NULL/0 if no wins yet)
-		if err := rows.Scan(&username, &wins, &best); err != nil {
-			http.Error(w, "Error scanning row: "+err.Error(), http.StatusInternalServerError)
+		e := LeaderboardEntry{Rank: offset + len(entries) + 1}
+		var best sql.NullInt64 // NULL if the player hasn't won by guessing in the window
+		if err := rows.Scan(&e.Player, &e.Rating, &e.Games, &e.Wins, &e.Losses, &e.Draws, &e.Misses, &e.Change, &best); err != nil {
+			return nil, 0, err
+		}
+		// If there's no best score yet, display "N/A"
+		e.BestScore = "N/A"
+		if best.Valid {
+			e.Best, e.HasBest = int(best.Int64), true
+			e.BestScore = fmt.Sprintf("%d", e.Best)
+		}
+		// Win rate and average misses need at least one game
+		e.WinRate, e.AvgMisses = "N/A", "N/A"
+		if e.Games > 0 {
+			e.WinRate = fmt.Sprintf("%.0f%%", 100*float64(e.Wins)/float64(e.Games))
+			e.AvgMisses = fmt.Sprintf("%.1f", float64(e.Misses)/float64(e.Games))
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// Helper: A player's rank on a board (0 if they have no rated games in its pool and window)
+func leaderboardRank(q leaderboardQuery, username string) (int, error) {
+	var rank int
+	err := db.DB.QueryRow(`
+        SELECT pos FROM (
+            SELECT username, ROW_NUMBER() OVER (ORDER BY `+q.order()+`) AS pos
+            FROM (`+leaderboardRows+`)
+        ) WHERE username = ?
+    `, q.Board.pool, q.Board.bots, q.since(), username).Scan(&rank)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return rank, err
+}
+
+// A board or window link above the leaderboard table
+type leaderboardTab struct {
+	Label  string
+	URL    string
+	Active bool
+}
+
+// Handler to display the leaderboard: one board and time window, a page at a time
+// (see BOARDS above for the query parameters, including ?format=json)
+func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	asJSON := r.URL.Query().Get("format") == "json"
+	q, err := parseLeaderboardQuery(r)
+	if err != nil {
+		if asJSON {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+		} else {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	// The logged-in user's own rank, and "find me": jump to the page it's on
+	viewer := requestUser(r)
+	findMe := r.URL.Query().Get("me") == "1"
+	if findMe && viewer == "" {
+		if asJSON {
+			writeJSONError(w, http.StatusUnauthorized, "log in to find your rank")
+		} else {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+		}
+		return
+	}
+	myRank := 0
+	if viewer != "" {
+		if myRank, err = leaderboardRank(q, viewer); err != nil {
+			if asJSON {
+				writeJSONError(w, http.StatusInternalServerError, "database error")
+			} else {
+				http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+	if findMe && myRank > 0 {
+		q.Page = (myRank-1)/q.PerPage + 1
+	}
+
+	entries, total, err := loadLeaderboard(q)
+	if err != nil {
+		// On DB query failure, return an HTTP 500 and the DB error message
+		if asJSON {
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+		} else {
+			http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	totalPages := (total + q.PerPage - 1) / q.PerPage
+
+	if asJSON {
+		body := map[string]interface{}{
+			"board":       q.Board.Key,
+			"window":      q.Window.Key,
+			"page":        q.Page,
+			"per_page":    q.PerPage,
+			"total":       total,
+			"total_pages": totalPages,
+			"entries":     apiLeaderboard(entries),
+		}
+		if viewer != "" {
+			// null if the user isn't on this board
+			var rank *int
+			if myRank > 0 {
+				rank = &myRank
+			}
+			body["user"], body["user_rank"] = viewer, rank
+		}
+		writeJSON(w, http.StatusOK, body)
+		return
+	}
+
+	// Links to the other boards and windows (back on page 1)
+	var boardTabs, windowTabs []leaderboardTab
+	for _, b := range leaderboardBoards {
+		tab := leaderboardQuery{Board: b, Window: q.Window, PerPage: q.PerPage}
+		boardTabs = append(boardTabs, leaderboardTab{Label: b.Label, URL: tab.URL(1), Active: b.Key == q.Board.Key})
+	}
+	for _, win := range leaderboardWindows {
+		tab := leaderboardQuery{Board: q.Board, Window: win, PerPage: q.PerPage}
+		windowTabs = append(windowTabs, leaderboardTab{Label: win.Label, URL: tab.URL(1), Active: win.Key == q.Window.Key})
+	}
+
+	// Render the leaderboard page ("leaderboard.html") with this page's entries
+	data := map[string]interface{}{
+		"Entries":    entries,
+		"Board":      q.Board,
+		"Window":     q.Window,
+		"Windowed":   q.Window.Days > 0,
+		"BoardTabs":  boardTabs,
+		"WindowTabs": windowTabs,
+		"Page":       q.Page,
+		"TotalPages": totalPages,
+		"Total":      total,
+		"Me":         viewer,
+		"MyRank":     myRank,
+		"NotRanked":  findMe && myRank == 0,
+	}
+	if viewer != "" {
+		data["FindMeURL"] = q.URL(1) + "&me=1"
+	}
+	if q.Page > 1 {
+		data["PrevURL"] = q.URL(q.Page - 1)
+	}
+	if q.Page < totalPages {
+		data["NextURL"] = q.URL(q.Page + 1)
+	}
+	utils.RenderPage(w, r, "leaderboard.html", data)
+}
+
+// -------- STATS --------
+//
+// The boards come from rating_history; the leaderboard table only keeps what the saved games
+// can't give: how many games each player finished and in how many they took the hint (older
+// games don't record who took a human-vs-human game's hint). Profiles show the hint rate.
+
+// Helper: Wrong letters guessed by one seat (1 or 2). Turns alternate starting with player 1,
+// except in host-mode games, where only player 2 guesses.
+func missesBy(game *models.Game, seat int) int {
+	return len(missedLetters(game, seat))
+}
+
+// Helper: The wrong letters one seat guessed, in order (see missesBy)
+func missedLetters(game *models.Game, seat int) []string {
+	var missed []string
+	for i, letter := range game.GuessHistory {
+		guesser := i%2 + 1
+		if game.CustomWord {
+			guesser = 2
+		}
+		if guesser == seat && !logic.InWord(game, letter) {
+			missed = append(missed, letter)
+		}
+	}
+	return missed
+}
+
+// Helper: Count a finished game on both players' leaderboard rows (the computer has none).
+// Wins and best score (fewest misses in a win) keep the all-time tally going from before games
+// were saved.
+func recordGameStats(game *models.Game) {
+	for i, player := range []string{game.Player1, game.Player2} {
+		if player == "" || player == logic.AIPlayerName {
+			continue
+		}
+		userID, err := lookupUserID(player)
+		if err != nil {
+			// User not found, log but continue
+			fmt.Println("Leaderboard update error: could not find user", player)
+			continue
+		}
+		hint := 0
+		if game.HintUsedBy == player {
+			hint = 1
+		}
+		win, best := 0, interface{}(nil)
+		if game.Winner == player {
+			win, best = 1, missesBy(game, i+1)
+		}
+		// best_score is 0 (the column default) on rows without a win yet, so a first win always sets it
+		_, err = db.DB.Exec(`
+            INSERT INTO leaderboard (player, games_played, hints_used, wins, best_score, last_updated)
+            VALUES (?, 1, ?, ?, ?, CURRENT_TIMESTAMP)
+            ON CONFLICT(player) DO UPDATE SET
+                games_played = games_played + 1,
+                hints_used = hints_used + excluded.hints_used,
+                wins = wins + excluded.wins,
+                best_score = CASE
+                    WHEN excluded.wins = 1 AND (wins = 0 OR best_score IS NULL OR excluded.best_score < best_score)
+                    THEN excluded.best_score ELSE best_score END,
+                last_updated = CURRENT_TIMESTAMP
+        `, userID, hint, win, best)
+		if err != nil {
+			fmt.Println("Leaderboard update error:", err)
+		}
+	}
+}
+
+// BackfillStats counts games played and hints taken from the saved games the first time the
+// server starts with them (only wins used to be counted). Does nothing once any game has been
+// counted. Hints from older human-vs-human games can't be credited (the hint's taker wasn't saved).
+// Call once after db.InitDB, before serving requests.
+func BackfillStats() {
+	var counted int
+	if err := db.DB.QueryRow("SELECT COUNT(*) FROM leaderboard WHERE games_played > 0").Scan(&counted); err != nil || counted > 0 {
+		return
+	}
+	rows, err := db.DB.Query(`
+        SELECT u.username, g.player2_name, g.mode, g.hint_used
+        FROM games g
+        JOIN users u ON g.player_id = u.id
+        WHERE g.finished_at IS NOT NULL AND g.winner != ''
+    `)
+	if err != nil {
+		fmt.Println("Stats backfill error:", err)
+		return
+	}
+	// Read everything before writing anything (there's only one database connection)
+	var past []*models.Game
+	for rows.Next() {
+		game := &models.Game{}
+		var mode string
+		var hintUsed bool
+		if err := rows.Scan(&game.Player1, &game.Player2, &mode, &hintUsed); err != nil {
+			fmt.Println("Stats backfill error:", err)
+			rows.Close()
 			return
 		}
-		// If best score is not set (0 or negative), display "N/A"
-		score := "N/A"
-		if best > 0 {
-			score = fmt.Sprintf("%d", best)
+		if hintUsed && mode != "versus" {
+			game.HintUsedBy = game.Player2 // host mode: only the guesser can take it
+			if mode == "ai" {
+				game.HintUsedBy = game.Player1
+			}
 		}
-		// Append leaderboard entry struct to list for rendering
-		entries = append(entries, LeaderboardEntry{
-			Player:    username,
-			Wins:      wins,
-			BestScore: score,
-		})
+		past = append(past, game)
 	}
+	rows.Close()
 
-	// Render the leaderboard page ("leaderboard.html"), passing the list of entries
-	utils.RenderPage(w, r, "leaderboard.html", map[string]interface{}{
-		"Entries": entries,
-	})
+	for _, game := range past {
+		recordGameStats(game)
+	}
+	if len(past) > 0 {
+		fmt.Printf("Counted %d earlier game(s) in leaderboard stats\n", len(past))
+	}
 }
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+	"wordgame/db"
+)
+
+// Helper: A player's leaderboard row (wins, best score, games played)
+func leaderboardRow(t *testing.T, userID int) (wins int, best sql.NullInt64, played int) {
+	t.Helper()
+	err := db.DB.QueryRow("SELECT wins, best_score, games_played FROM leaderboard WHERE player = ?", userID).
+		Scan(&wins, &best, &played)
+	if err != nil {
+		t.Fatalf("leaderboard row for user %d: %v", userID, err)
+	}
+	return wins, best, played
+}
+
+// Finished games keep counting wins and the best score (fewest misses in a win) on the
+// leaderboard table, which still holds the results from before games were saved.
+func TestRecordGameStatsWins(t *testing.T) {
+	winner, loser := freshUser(t, "stats_winner"), freshUser(t, "stats_loser")
+	for _, misses := range []int{2, 0, 1} {
+		game := testGame(t, "ox", loser, winner)
+		game.GuessHistory = []string{"q", "z", "w", "v", "k", "j"}[:2*misses]
+		for _, letter := range game.GuessHistory {
+			game.GuessedLetters[letter] = true
+		}
+		game.GuessHistory = append(game.GuessHistory, "o", "x")
+		game.GuessedLetters["o"], game.GuessedLetters["x"] = true, true
+		game.Status, game.Winner = "finished", winner
+		recordGameStats(game)
+	}
+
+	wins, best, played := leaderboardRow(t, testUser(t, winner))
+	if wins != 3 || !best.Valid || best.Int64 != 0 || played != 3 {
+		t.Errorf("winner: wins %d, best score %v, games %d; want 3 wins, best 0, 3 games", wins, best, played)
+	}
+	wins, best, played = leaderboardRow(t, testUser(t, loser))
+	if wins != 0 || best.Valid || played != 3 {
+		t.Errorf("loser: wins %d, best score %v, games %d; want no wins or best score, 3 games", wins, best, played)
+	}
+}
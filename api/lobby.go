@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wordgame/logic"
+	"wordgame/models"
+	"wordgame/utils"
+	"wordgame/words"
+
+	"github.com/gorilla/websocket"
+)
+
+// -------- LOBBY --------
+//
+// The lobby lists every game still waiting for player 2, so nobody has to pass the 4-letter
+// code around. "Quick Match" joins the longest-waiting open game with compatible settings,
+// or opens a new one and waits in the waiting room. Lobby pages get the list pushed over
+// /ws/lobby whenever it changes; the waiting room hears "player 2 joined" over the game's
+// own WebSocket (see joinGame).
+
+// One open game as shown in the lobby
+type lobbyGame struct {
+	ID         string `json:"id"`
+	Host       string `json:"host"`
+	Mode       string `json:"mode"`        // "versus", or "custom" when the host picked the word
+	WordLength int    `json:"word_length"` // letters only (see words.LetterCount)
+	MaxGuesses int    `json:"max_guesses"`
+	Category   string `json:"category,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Language   string `json:"language"`
+	AgeSeconds int    `json:"age_seconds"` // how long the host has been waiting
+}
+
+// Age for the lobby table, e.g. "just now", "5m", "2h" (lobby.html formats pushed updates the same way)
+func (g lobbyGame) Age() string {
+	switch {
+	case g.AgeSeconds < 60:
+		return "just now"
+	case g.AgeSeconds < 3600:
+		return fmt.Sprintf("%dm", g.AgeSeconds/60)
+	default:
+		return fmt.Sprintf("%dh", g.AgeSeconds/3600)
+	}
+}
+
+// Settings summary for the lobby table, e.g. "animals, hard, en"
+func (g lobbyGame) Details() string {
+	parts := []string{}
+	if g.Mode == "custom" {
+		parts = append(parts, "host's word")
+	}
+	if g.Category != "" {
+		parts = append(parts, g.Category)
+	}
+	if g.Difficulty != "" {
+		parts = append(parts, g.Difficulty)
+	}
+	parts = append(parts, g.Language)
+	return strings.Join(parts, ", ")
+}
+
+// Message pushed to /ws/lobby connections
+type lobbyMessage struct {
+	Action string      `json:"action"` // always "lobby"
+	Games  []lobbyGame `json:"games"`
+}
+
+var (
+	// Open connections to /ws/lobby
+	lobbyConns = make(map[*websocket.Conn]bool)
+	lobbyMu    sync.Mutex // Guards lobbyConns and writes to them (one writer per connection)
+
+	// Signals the lobby broadcaster that the open-game list changed (buffered: extra signals coalesce)
+	lobbyDirty = make(chan struct{}, 1)
+)
+
+// Helper: Every game waiting for player 2, longest-waiting first.
+// Only waiting games are locked, so a busy game in progress never holds up the lobby.
+func openGames() []lobbyGame {
+	open := []lobbyGame{}
+	games.EachWaiting(func(game *models.Game) {
+		if game.Status != "waiting" || game.Player2 != "" {
+			return
+		}
+		open = append(open, lobbyGame{
+			ID:         game.ID,
+			Host:       game.Player1,
+			Mode:       gameMode(game),
+			WordLength: words.LetterCount(game.Word),
+			MaxGuesses: game.MaxIncorrectGuesses,
+			Category:   game.Category,
+			Difficulty: game.Difficulty,
+			Language:   logic.GameLanguage(game).Code,
+			// StartedAt is the creation time until player 2 joins (joinGame resets it)
+			AgeSeconds: int(time.Since(game.StartedAt).Seconds()),
+		})
+	})
+	sort.Slice(open, func(i, j int) bool {
+		if open[i].AgeSeconds != open[j].AgeSeconds {
+			return open[i].AgeSeconds > open[j].AgeSeconds
+		}
+		return open[i].ID < open[j].ID
+	})
+	return open
+}
+
+// Helper: Does an open game satisfy the options a quick-match player asked for?
+// Options left out (zero / "any") match anything.
+func (o gameOptions) matches(game lobbyGame, settings wordSettings) bool {
+	switch {
+	case game.Mode != "versus":
+		return false // host-picked words are joined deliberately, from the list
+	case o.WordLength > 0 && game.WordLength != settings.Length:
+		return false
+	case o.MaxGuesses > 0 && game.MaxGuesses != o.MaxGuesses:
+		return false
+	case settings.Difficulty != words.DifficultyAny && game.Difficulty != string(settings.Difficulty):
+		return false
+	case settings.Category != "" && game.Category != settings.Category:
+		return false
+	}
+	return game.Language == settings.Language.Code
+}
+
+// Helper: Pair player with the longest-waiting compatible open game, or open a new game for them.
+// waiting reports whether the player is now hosting (and waiting) rather than playing.
+func quickMatch(player string, opts gameOptions) (id string, waiting bool, err error) {
+	settings, err := opts.wordSettings()
+	if err != nil {
+		return "", false, errBadSettings
+	}
+	for _, game := range openGames() {
+		if !opts.matches(game, settings) {
+			continue
+		}
+		if game.Host == player {
+			return game.ID, true, nil // already waiting in a matching game: don't open a second one
+		}
+		// The list is a snapshot; joinGame re-checks under the game's lock, so on a lost race try the next one
+		if joinGame(game.ID, player) == nil {
+			return game.ID, false, nil
+		}
+	}
+	id, err = createGame(player, opts, false)
+	return id, true, err
+}
+
+// Helper: Note that the open-game list changed. Never blocks, so it's safe to call anywhere.
+func lobbyChanged() {
+	select {
+	case lobbyDirty <- struct{}{}:
+	default: // a push is already pending and will include this change
+	}
+}
+
+// StartLobbyBroadcaster pushes the open-game list to every /ws/lobby connection whenever it changes.
+func StartLobbyBroadcaster() {
+	go func() {
+		for range lobbyDirty {
+			broadcastLobby()
+		}
+	}()
+}
+
+// Helper: Send the current open-game list to every lobby connection
+func broadcastLobby() {
+	data, err := json.Marshal(lobbyMessage{Action: "lobby", Games: openGames()})
+	if err != nil {
+		fmt.Println("Error marshaling lobby:", err)
+		return
+	}
+	lobbyMu.Lock()
+	defer lobbyMu.Unlock()
+	for conn := range lobbyConns {
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			conn.Close()
+			delete(lobbyConns, conn) // the reader in LobbyWebSocketHandler exits on its own
+		}
+	}
+}
+
+// WebSocket handler for /ws/lobby: sends the open-game list now and again whenever it changes.
+// Push-only; anything the client sends is ignored.
+func LobbyWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		fmt.Println("WebSocket upgrade error:", err)
+		return
+	}
+	data, _ := json.Marshal(lobbyMessage{Action: "lobby", Games: openGames()})
+
+	lobbyMu.Lock()
+	lobbyConns[conn] = true
+	conn.WriteMessage(websocket.TextMessage, data)
+	lobbyMu.Unlock()
+
+	defer func() {
+		lobbyMu.Lock()
+		delete(lobbyConns, conn)
+		lobbyMu.Unlock()
+		conn.Close()
+	}()
+
+	// Read until the client goes away (gorilla needs a reader to notice the close)
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// Lobby page: open games to join, plus the Quick Match form
+func LobbyHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := getUser(w, r); !ok {
+		return
+	}
+	utils.RenderPage(w, r, "lobby.html", map[string]interface{}{
+		"Games":      openGames(),
+		"Categories": words.Categories(),
+		"Languages":  words.Languages(),
+	})
+}
+
+// HTTP POST handler: quick match with the (optional) settings from the lobby form
+func QuickMatchHandler(w http.ResponseWriter, r *http.Request) {
+	player, ok := getUser(w, r)
+	if !ok {
+		return
+	}
+
+	r.ParseForm()
+	id, waiting, err := quickMatch(player, parseGameOptions(r))
+	if err != nil {
+		redirectCreateError(w, r, err)
+		return
+	}
+	setGameCookie(w, id)
+	if waiting {
+		http.Redirect(w, r, "/wait", http.StatusSeeOther) // nobody compatible yet: wait for them
+	} else {
+		http.Redirect(w, r, "/gameplay", http.StatusSeeOther)
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"testing"
+	"wordgame/models"
+)
+
+// The lobby gives the word's length in letters, like the length picked on the create forms.
+func TestOpenGamesWordLength(t *testing.T) {
+	game := testGame(t, "ice-cream cone", "lobby_host", "")
+	games.Update(game.ID, func(game *models.Game) error {
+		game.Status = "waiting"
+		return nil
+	})
+
+	for _, open := range openGames() {
+		if open.ID != game.ID {
+			continue
+		}
+		if open.WordLength != 12 {
+			t.Errorf("WordLength = %d, want 12 (spaces and punctuation aren't letters)", open.WordLength)
+		}
+		return
+	}
+	t.Fatal("waiting game not listed in the lobby")
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"wordgame/matchmaking"
+	"wordgame/session"
+	"wordgame/utils"
+	"wordgame/words"
+)
+
+// -------- MATCHMAKING --------
+//
+// Ranked matchmaking: /matchmaking opens /ws/matchmaking, which queues the player with their
+// rating for as long as the connection stays open. When the queue pairs them (see package
+// matchmaking) the game is created already started, and both pages are sent through
+// /wait?game_id=... to /gameplay. If nobody suitable turns up in time, the page offers a game
+// against the computer instead.
+
+// The ranked queue (paired once a second by StartMatchmaker)
+var matchQueue = matchmaking.New(matchmaking.DefaultConfig, createMatchedGame)
+
+// Message sent to /ws/matchmaking connections
+type matchmakingMessage struct {
+	Action   string `json:"action"` // "queued", "matched", "timeout" or "error"
+	GameID   string `json:"game_id,omitempty"`
+	Opponent string `json:"opponent,omitempty"`
+	Rating   int    `json:"rating,omitempty"`
+	Payload  string `json:"payload,omitempty"` // error text
+}
+
+// StartMatchmaker starts pairing queued players in the background.
+func StartMatchmaker() {
+	matchQueue.Start()
+}
+
+// Helper: Create the game for a matched pair, already in progress (the longer-waiting player goes first).
+// The pool is the language both players queued for; the word uses the default settings.
+func createMatchedGame(a, b matchmaking.Ticket) (string, error) {
+	opts := gameOptions{Language: a.Pool}
+	settings, err := opts.wordSettings()
+	if err != nil {
+		return "", errBadSettings
+	}
+	word, rated, err := pickWord(settings)
+	if err != nil {
+		return "", errNoWord
+	}
+	game := newGame(word, rated, settings, opts.maxGuesses())
+	game.Player1 = a.Player
+	game.Player2 = b.Player
+	game.Status = "in_progress"
+	if err := registerGame(game); err != nil {
+		return "", err
+	}
+	return game.ID, nil
+}
+
+// Matchmaking page: pick a language and search for a ranked opponent
+func MatchmakingHandler(w http.ResponseWriter, r *http.Request) {
+	player, ok := getUser(w, r)
+	if !ok {
+		return
+	}
+	utils.RenderPage(w, r, "matchmaking.html", map[string]interface{}{
+		"Rating":    playerRating(player),
+		"Languages": words.Languages(),
+		"Searching": matchQueue.Len(),
+	})
+}
+
+// WebSocket handler for /ws/matchmaking?language=en: queues the session user until they're
+// matched, time out, or close the connection (which takes them out of the queue).
+func MatchmakingWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := session.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	lang, err := words.LanguageByCode(r.URL.Query().Get("language"))
+	if err != nil {
+		http.Error(w, "Unknown language", http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		fmt.Println("WebSocket upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+
+	rating := playerRating(user.Username)
+	result := matchQueue.Join(user.Username, rating, lang.Code)
+	conn.WriteJSON(matchmakingMessage{Action: "queued", Rating: rating})
+
+	// Watch for the page going away (gorilla needs a reader to notice the close)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case res := <-result:
+		switch {
+		case res.Err != nil:
+			conn.WriteJSON(matchmakingMessage{Action: "error", Payload: res.Err.Error()})
+		case res.TimedOut:
+			conn.WriteJSON(matchmakingMessage{Action: "timeout"})
+		default:
+			conn.WriteJSON(matchmakingMessage{Action: "matched", GameID: res.GameID, Opponent: res.Opponent})
+		}
+	case <-closed:
+		matchQueue.Leave(user.Username, result)
+	}
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"wordgame/achievements"
+	"wordgame/db"
+	"wordgame/logic"
+	"wordgame/rating"
+	"wordgame/utils"
+)
+
+// -------- PROFILES --------
+//
+// /u/{username} sums up one player's record: results against people and against the computer,
+// misses, hint use, the letters they miss most, the word lengths they play most, win streaks,
+// their latest games and the achievements they've unlocked. The numbers are worked out from the
+// games table (one pass over the player's finished games, oldest first), except hint use, which
+// comes from their leaderboard row: older games don't record who took a human-vs-human game's hint.
+
+// How many letters, word lengths and recent games a profile lists
+const (
+	profileTopLetters  = 5
+	profileTopLengths  = 3
+	profileRecentGames = 5
+)
+
+// Wins, losses and draws against one kind of opponent
+type ProfileRecord struct {
+	Wins, Losses, Draws int
+}
+
+// Played is the number of games in the record
+func (p ProfileRecord) Played() int {
+	return p.Wins + p.Losses + p.Draws
+}
+
+// WinRate formats the share of games won, e.g. "64%" ("N/A" before the first game)
+func (p ProfileRecord) WinRate() string {
+	if p.Played() == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(p.Wins)/float64(p.Played()))
+}
+
+// A letter (or word length) and how many times it came up
+type ProfileCount struct {
+	Key   string
+	Count int
+}
+
+// Everything shown on /u/{username}
+type PlayerProfile struct {
+	Player         string
+	Bot            bool          // a bot account (see /settings)
+	VersusRating   int           // Elo rating against people
+	AIRating       int           // Elo rating against the computer
+	Played         int           // finished games of every mode
+	VsHumans       ProfileRecord // versus and host-mode games
+	VsAI           ProfileRecord // games against the computer
+	Misses         int           // wrong letters the player guessed, over all games
+	HintsUsed      int           // games in which the player took the hint
+	HintGames      int           // games the hint count is out of (leaderboard games_played)
+	MissedLetters  []ProfileCount
+	WordLengths    []ProfileCount // Key is the length, e.g. "5"
+	CurrentStreak  int            // wins in a row up to the latest game
+	BestStreak     int
+	Recent         []HistoryEntry
+	Achievements   []UnlockedAchievement
+	AchievementsOf int // how many achievements there are in all
+}
+
+// AvgMisses formats wrong letters per game, e.g. "2.3" ("N/A" before the first game)
+func (p PlayerProfile) AvgMisses() string {
+	if p.Played == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f", float64(p.Misses)/float64(p.Played))
+}
+
+// HintRate formats the share of games in which the player took the hint, e.g. "25%"
+func (p PlayerProfile) HintRate() string {
+	if p.HintGames == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(p.HintsUsed)/float64(p.HintGames))
+}
+
+// Helper: The n most common keys, most common first (ties in key order)
+func topCounts(counts map[string]int, n int) []ProfileCount {
+	var out []ProfileCount
+	for key, count := range counts {
+		out = append(out, ProfileCount{Key: key, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// Helper: Letters in the word a player has to guess (spaces, hyphens and the like don't count)
+func guessableLength(word string) int {
+	n := 0
+	for _, c := range word {
+		if logic.IsGuessable(c) {
+			n++
+		}
+	}
+	return n
+}
+
+// Helper: Build a player's profile (sql.ErrNoRows if there's no such user)
+func loadProfile(username string) (*PlayerProfile, error) {
+	p := &PlayerProfile{Player: username}
+	var userID int
+	err := db.DB.QueryRow("SELECT id, is_bot FROM users WHERE username = ?", username).Scan(&userID, &p.Bot)
+	if err != nil {
+		return nil, err
+	}
+	// Ratings in both pools (rating.Initial until the first rated game)
+	p.VersusRating, p.AIRating = rating.Initial, rating.Initial
+	ratingRows, err := db.DB.Query("SELECT pool, rating FROM ratings WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	for ratingRows.Next() {
+		var pool string
+		var poolRating int
+		if err := ratingRows.Scan(&pool, &poolRating); err != nil {
+			ratingRows.Close()
+			return nil, err
+		}
+		if pool == poolAI {
+			p.AIRating = poolRating
+		} else {
+			p.VersusRating = poolRating
+		}
+	}
+	ratingRows.Close()
+	// Hint use (players with no finished games have no leaderboard row)
+	err = db.DB.QueryRow("SELECT hints_used, games_played FROM leaderboard WHERE player = ?", userID).Scan(&p.HintsUsed, &p.HintGames)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Every finished game the player took part in, oldest first (streaks need the order)
+	rows, err := db.DB.Query(`
+        SELECT g.player_id = ?, g.mode, g.word, g.guessed_letters, g.language, g.accent_insensitive, g.winner
+        FROM games g
+        WHERE (g.player_id = ? OR g.player2_id = ?) AND g.finished_at IS NOT NULL
+        ORDER BY g.finished_at ASC, g.id ASC
+    `, userID, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	missed := make(map[string]int)
+	lengths := make(map[string]int)
+	for rows.Next() {
+		var isPlayer1, accentInsensitive bool
+		var mode, word, guesses, language, winner string
+		if err := rows.Scan(&isPlayer1, &mode, &word, &guesses, &language, &accentInsensitive, &winner); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		game := storedGame(mode, word, guesses, language, accentInsensitive)
+		game.Winner = winner
+		seat := 2
+		if isPlayer1 {
+			seat = 1
+		}
+
+		p.Played++
+		record := &p.VsHumans
+		if mode == "ai" {
+			record = &p.VsAI
+		}
+		switch game.Winner {
+		case username:
+			record.Wins++
+			p.CurrentStreak++
+			p.BestStreak = max(p.BestStreak, p.CurrentStreak)
+		case "Draw", "":
+			record.Draws++
+			p.CurrentStreak = 0
+		default:
+			record.Losses++
+			p.CurrentStreak = 0
+		}
+
+		// The player's own wrong guesses
+		for _, letter := range missedLetters(game, seat) {
+			p.Misses++
+			missed[strings.ToUpper(letter)]++
+		}
+		// A host picked their own word, so only the words they had to guess count
+		if !(game.CustomWord && seat == 1) {
+			lengths[fmt.Sprintf("%d", guessableLength(game.Word))]++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	p.MissedLetters = topCounts(missed, profileTopLetters)
+	p.WordLengths = topCounts(lengths, profileTopLengths)
+
+	if p.Achievements, err = loadAchievements(userID); err != nil {
+		return nil, err
+	}
+	p.AchievementsOf = len(achievements.All)
+
+	if p.Recent, _, err = loadHistory(userID, 1, profileRecentGames); err != nil {
+		return nil, err
+	}
+	for i := range p.Recent {
+		p.Recent[i].Result = resultFor(p.Recent[i], username)
+	}
+	return p, nil
+}
+
+// GET /u/{username}: a player's profile and statistics (no login needed)
+func ProfileHandler(w http.ResponseWriter, r *http.Request) {
+	profile, err := loadProfile(r.PathValue("username"))
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	utils.RenderPage(w, r, "profile.html", map[string]interface{}{
+		"Profile": profile,
+	})
+}
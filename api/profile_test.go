@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+	"wordgame/logic"
+	"wordgame/models"
+)
+
+// A folded guess in an accent-insensitive game is a hit, in the saved game as in the live one.
+func TestProfileAccentInsensitiveMisses(t *testing.T) {
+	player := freshUser(t, "accent_fan")
+	game := &models.Game{
+		ID:                  "pao1",
+		Word:                "pão",
+		Language:            "pt",
+		AccentInsensitive:   true,
+		GuessedLetters:      make(map[string]bool),
+		MaxIncorrectGuesses: 6,
+		PlayerTurn:          1,
+		Player1:             player,
+		Player2:             logic.AIPlayerName,
+		Status:              "in_progress",
+		StartedAt:           time.Now(),
+	}
+	// Turns alternate: the player guesses a, z and o; the computer x and p
+	for _, letter := range []string{"a", "x", "z", "p", "o"} {
+		logic.RegisterGuess(game, letter)
+	}
+	if game.Status != "finished" || game.Winner != player || missesBy(game, 1) != 1 {
+		t.Fatalf("live game: %s, winner %q, %d player misses; want won by the player with 1 miss (z)",
+			game.Status, game.Winner, missesBy(game, 1))
+	}
+	if saveFinishedGame(game) == 0 {
+		t.Fatal("saveFinishedGame failed")
+	}
+
+	p, err := loadProfile(player)
+	if err != nil {
+		t.Fatalf("loadProfile: %v", err)
+	}
+	if p.Misses != 1 {
+		t.Errorf("profile misses = %d, want 1 (only z; a matched ã)", p.Misses)
+	}
+	for _, c := range p.MissedLetters {
+		if c.Key == "A" {
+			t.Errorf("'a' counted as a missed letter in an accent-insensitive game")
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"wordgame/db"
+	"wordgame/models"
+	"wordgame/rating"
+)
+
+// -------- RATINGS --------
+//
+// Every finished game between two people (host-mode games included) moves both players'
+// "versus" Elo rating. Games against the computer move a separate "ai" rating, with the
+// computer as a fixed-strength opponent, so beating the AI over and over can't lift anyone
+// up the main leaderboard. Every change is kept in rating_history.
+
+// Rating pools (ratings.pool)
+const (
+	poolVersus = "versus"
+	poolAI     = "ai"
+)
+
+// The computer's fixed rating in the "ai" pool
+const aiRating = rating.Initial
+
+// How rating_history.created_at is written: the same layout (and UTC) as SQLite's
+// CURRENT_TIMESTAMP, so the time windows on the leaderboard compare as plain strings
+const historyTimeFormat = "2006-01-02 15:04:05"
+
+// One player's side of a rated game
+type ratingSide struct {
+	userID   int
+	opponent string
+	misses   int // wrong letters this player guessed
+	before   int // rating before this game
+	games    int // rated games finished before this one
+	score    rating.Score
+}
+
+// Helper: A player's score in a finished game (a draw when nobody won)
+func scoreFor(game *models.Game, player string) rating.Score {
+	switch game.Winner {
+	case player:
+		return rating.Win
+	case "Draw", "":
+		return rating.Draw
+	default:
+		return rating.Loss
+	}
+}
+
+// Helper: "win", "loss" or "draw" for rating_history
+func resultName(score rating.Score) string {
+	switch score {
+	case rating.Win:
+		return "win"
+	case rating.Loss:
+		return "loss"
+	default:
+		return "draw"
+	}
+}
+
+// Helper: A user's rating and rated-game count in a pool (rating.Initial and 0 before their first rated game)
+func loadRating(tx *sql.Tx, userID int, pool string) (int, int, error) {
+	r, games := rating.Initial, 0
+	err := tx.QueryRow("SELECT rating, games FROM ratings WHERE user_id = ? AND pool = ?", userID, pool).Scan(&r, &games)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return r, games, err
+}
+
+// Helper: Apply a finished game to its players' ratings and record the changes.
+// gameRowID is the game's games.id for rating_history (0 if it couldn't be saved) and
+// finishedAt is when the game ended (the time the leaderboard's weekly/monthly boards go by).
+// Called once per game, right after saveFinishedGame; daily puzzles are never rated.
+func updateRatings(game *models.Game, gameRowID int64, finishedAt time.Time) {
+	if game.Daily {
+		return
+	}
+	tx, err := db.DB.Begin()
+	if err != nil {
+		fmt.Println("Rating update error:", err)
+		return
+	}
+	defer tx.Rollback() // no-op once committed
+
+	pool := poolVersus
+	players := []struct{ name, opponent string }{{game.Player1, game.Player2}, {game.Player2, game.Player1}}
+	if gameMode(game) == "ai" {
+		pool = poolAI
+		players = players[:1] // the computer has no rating of its own
+	}
+
+	// Read every rating first: both updates use the ratings from *before* this game
+	sides := make([]ratingSide, len(players))
+	for i, p := range players {
+		side := ratingSide{opponent: p.opponent, misses: missesBy(game, i+1), score: scoreFor(game, p.name)}
+		if err := tx.QueryRow("SELECT id FROM users WHERE username = ?", p.name).Scan(&side.userID); err != nil {
+			fmt.Println("Rating update error: could not find user", p.name)
+			return
+		}
+		if side.before, side.games, err = loadRating(tx, side.userID, pool); err != nil {
+			fmt.Println("Rating update error:", err)
+			return
+		}
+		sides[i] = side
+	}
+
+	var gameRef sql.NullInt64
+	if gameRowID > 0 {
+		gameRef = sql.NullInt64{Int64: gameRowID, Valid: true}
+	}
+	for i, side := range sides {
+		opponentRating := aiRating
+		if pool == poolVersus {
+			opponentRating = sides[1-i].before
+		}
+		after := rating.Update(side.before, opponentRating, side.score, side.games)
+
+		_, err := tx.Exec(`
+            INSERT INTO ratings (user_id, pool, rating, games, updated_at)
+            VALUES (?, ?, ?, 1, CURRENT_TIMESTAMP)
+            ON CONFLICT(user_id, pool) DO UPDATE SET
+                rating = excluded.rating,
+                games = games + 1,
+                updated_at = CURRENT_TIMESTAMP
+        `, side.userID, pool, after)
+		if err != nil {
+			fmt.Println("Rating update error:", err)
+			return
+		}
+		_, err = tx.Exec(`
+            INSERT INTO rating_history (user_id, pool, game_id, opponent, result, old_rating, new_rating, misses, created_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+        `, side.userID, pool, gameRef, side.opponent, resultName(side.score), side.before, after,
+			side.misses, finishedAt.UTC().Format(historyTimeFormat))
+		if err != nil {
+			fmt.Println("Rating history error:", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Println("Rating update error:", err)
+	}
+}
+
+// Helper: A player's rating against other people (what matchmaking pairs on)
+func playerRating(username string) int {
+	r := rating.Initial
+	err := db.DB.QueryRow(`
+        SELECT r.rating
+        FROM ratings r
+        JOIN users u ON r.user_id = u.id
+        WHERE u.username = ? AND r.pool = ?
+    `, username, poolVersus).Scan(&r)
+	if err != nil && err != sql.ErrNoRows {
+		fmt.Println("Rating lookup error:", err)
+	}
+	return r
+}
+
+// BackfillRatings rates the games that finished before ratings existed, oldest first, so the
+// leaderboard isn't empty after an upgrade. Does nothing once any game has been rated, apart from
+// filling in misses on history rows saved before they were recorded (see fillHistoryMisses).
+// Call once after db.InitDB, before serving requests.
+func BackfillRatings() {
+	defer fillHistoryMisses()
+
+	var rated int
+	if err := db.DB.QueryRow("SELECT COUNT(*) FROM rating_history").Scan(&rated); err != nil || rated > 0 {
+		return
+	}
+	rows, err := db.DB.Query(`
+        SELECT g.id, u.username, g.player2_name, g.winner, g.mode, g.word, g.guessed_letters, g.language,
+               g.accent_insensitive, g.finished_at
+        FROM games g
+        JOIN users u ON g.player_id = u.id
+        WHERE g.finished_at IS NOT NULL AND g.winner != '' AND g.player2_name != ''
+        ORDER BY g.finished_at ASC, g.id ASC
+    `)
+	if err != nil {
+		fmt.Println("Rating backfill error:", err)
+		return
+	}
+	// Read everything before rating anything (there's only one database connection)
+	type pastGame struct {
+		id         int64
+		game       *models.Game
+		finishedAt time.Time
+	}
+	var past []pastGame
+	for rows.Next() {
+		var p pastGame
+		var player1, player2, winner, mode, word, guesses, language string
+		var accentInsensitive bool
+		if err := rows.Scan(&p.id, &player1, &player2, &winner, &mode,
+			&word, &guesses, &language, &accentInsensitive, &p.finishedAt); err != nil {
+			fmt.Println("Rating backfill error:", err)
+			rows.Close()
+			return
+		}
+		p.game = storedGame(mode, word, guesses, language, accentInsensitive)
+		p.game.Player1, p.game.Player2, p.game.Winner = player1, player2, winner
+		past = append(past, p)
+	}
+	rows.Close()
+
+	for _, p := range past {
+		updateRatings(p.game, p.id, p.finishedAt)
+	}
+	if len(past) > 0 {
+		fmt.Printf("Rated %d earlier game(s)\n", len(past))
+	}
+}
+
+// Helper: Work out misses for rating_history rows saved before the column existed (NULL), and
+// move their created_at to when the game finished: rows written by the first backfill carry the
+// time of the upgrade, which would put every old game on this week's leaderboard.
+func fillHistoryMisses() {
+	rows, err := db.DB.Query(`
+        SELECT h.id, h.user_id = g.player_id, g.mode, g.word, g.guessed_letters, g.language,
+               g.accent_insensitive, g.finished_at
+        FROM rating_history h
+        JOIN games g ON g.id = h.game_id
+        WHERE h.misses IS NULL AND g.finished_at IS NOT NULL
+    `)
+	if err != nil {
+		fmt.Println("Rating history backfill error:", err)
+		return
+	}
+	type fix struct {
+		id, misses int
+		finishedAt time.Time
+	}
+	var fixes []fix
+	for rows.Next() {
+		var f fix
+		var isPlayer1, accentInsensitive bool
+		var mode, word, guesses, language string
+		if err := rows.Scan(&f.id, &isPlayer1, &mode, &word, &guesses, &language, &accentInsensitive, &f.finishedAt); err != nil {
+			fmt.Println("Rating history backfill error:", err)
+			rows.Close()
+			return
+		}
+		game := storedGame(mode, word, guesses, language, accentInsensitive)
+		seat := 2
+		if isPlayer1 {
+			seat = 1
+		}
+		f.misses = missesBy(game, seat)
+		fixes = append(fixes, f)
+	}
+	rows.Close()
+
+	for _, f := range fixes {
+		_, err := db.DB.Exec("UPDATE rating_history SET misses = ?, created_at = ? WHERE id = ?",
+			f.misses, f.finishedAt.UTC().Format(historyTimeFormat), f.id)
+		if err != nil {
+			fmt.Println("Rating history backfill error:", err)
+			return
+		}
+	}
+}
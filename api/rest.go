@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"wordgame/logic"
+	"wordgame/models"
+	"wordgame/store"
+)
+
+// -------- JSON API v1 --------
+//
+// /api/v1 mirrors the HTML/WebSocket game flow for bots and mobile clients:
+//
+//	POST /api/v1/games             create a human-vs-human game (body: game options)  -> 201 state
+//	POST /api/v1/games/ai          create a game against the computer (+ ai_strategy) -> 201 state
+//	POST /api/v1/games/{id}/join   take seat 2                                        -> 200 state
+//	GET  /api/v1/games/{id}        current state, from the caller's point of view     -> 200 state
+//	POST /api/v1/games/{id}/guess  body {"letter": "e"}                               -> 200 state
+//	POST /api/v1/games/{id}/hint   use (or re-read) the game's hint                   -> 200 {"hint": "..."}
+//	GET  /api/v1/leaderboard       top 10 by rating: players, vs. computer, bots      -> 200 {"entries": [...], "ai": [...], "bots": [...]}
+//	GET  /api/v1/lobby             games waiting for player 2                         -> 200 {"games": [...]}
+//	POST /api/v1/lobby/quick       quick match (body: game options, all optional)     -> 200 state (joined) / 201 state (waiting)
+//
+// Requests are authenticated by the login session or an API token ("Authorization: Bearer ..."). Errors are {"error": "..."} with a
+// matching status code. Guesses go through playGuess, exactly like WebSocket guesses.
+
+// Largest JSON request body accepted
+const apiMaxBody = 1 << 20
+
+// A game as seen by one user through the JSON API
+type apiGameState struct {
+	ID          string   `json:"id"`
+	Mode        string   `json:"mode"`   // "versus", "ai", "custom"
+	Status      string   `json:"status"` // "waiting", "in_progress", "finished"
+	Player1     string   `json:"player1"`
+	Player2     string   `json:"player2"`
+	Seat        int      `json:"seat"` // caller's seat: 1, 2, or 0 when only watching
+	Turn        int      `json:"turn"` // seat whose turn it is
+	YourTurn    bool     `json:"your_turn"`
+	DisplayWord string   `json:"display_word"` // e.g. "_ a _ _ e"
+	Correct     []string `json:"correct"`
+	Wrong       []string `json:"wrong"`
+	Guesses     []string `json:"guesses"`    // in the order they were made
+	Candidates  int      `json:"candidates"` // dictionary words that still fit the board (0 = not counted: the word isn't in the solver's word list)
+	Misses      int      `json:"misses"`
+	MaxMisses   int      `json:"max_misses"`
+	HintUsed    bool     `json:"hint_used"`
+	Hint        string   `json:"hint,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Difficulty  string   `json:"difficulty,omitempty"`
+	Language    string   `json:"language"`
+	AIStrategy  string   `json:"ai_strategy,omitempty"` // games against the computer: a logic.AIStrategies key, e.g. "solver"
+	Winner      string   `json:"winner,omitempty"`
+	Word        string   `json:"word,omitempty"` // only once the game is over (or for the word setter)
+}
+
+// Helper: Build the API view of a game for username; caller holds the game's lock
+func apiState(game *models.Game, username string) apiGameState {
+	seat := seatOf(game, username)
+	state := apiGameState{
+		ID:          game.ID,
+		Mode:        gameMode(game),
+		Status:      game.Status,
+		Player1:     game.Player1,
+		Player2:     game.Player2,
+		Turn:        game.PlayerTurn,
+		YourTurn:    seat != "" && seat == strconv.Itoa(game.PlayerTurn),
+		DisplayWord: game.DisplayWord,
+		Correct:     []string{},
+		Wrong:       []string{},
+		Guesses:     append([]string{}, game.GuessHistory...),
+		Candidates:  game.Candidates,
+		Misses:      game.IncorrectGuesses,
+		MaxMisses:   game.MaxIncorrectGuesses,
+		HintUsed:    game.HasUsedHint,
+		Hint:        game.HintText,
+		Category:    game.Category,
+		Difficulty:  game.Difficulty,
+		Language:    logic.GameLanguage(game).Code,
+		AIStrategy:  game.AIStrategy,
+		Winner:      game.Winner,
+	}
+	state.Seat, _ = strconv.Atoi(seat) // "" (watching) -> 0
+	for letter := range game.GuessedLetters {
+		if logic.InWord(game, letter) {
+			state.Correct = append(state.Correct, letter)
+		} else {
+			state.Wrong = append(state.Wrong, letter)
+		}
+	}
+	sort.Strings(state.Correct)
+	sort.Strings(state.Wrong)
+	state.Word = visibleWord(game, seat)
+	return state
+}
+
+// Helper: The logged-in user, or a 401 JSON error
+func apiUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	username := requestUser(r)
+	if username == "" {
+		writeJSONError(w, http.StatusUnauthorized, "login required")
+		return "", false
+	}
+	return username, true
+}
+
+// Helper: Decode an optional JSON request body into v (an empty body leaves v untouched)
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, apiMaxBody)).Decode(v)
+	if err != nil && err != io.EOF {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// Helper: Respond with the caller's view of a game
+func writeGameState(w http.ResponseWriter, status int, gameID, username string) {
+	var state apiGameState
+	err := games.View(gameID, func(game *models.Game) error {
+		state = apiState(game, username)
+		return nil
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "game not found")
+		return
+	}
+	writeJSON(w, status, state)
+}
+
+// Helper: Create a game from the JSON options in the request body
+func apiCreateGame(w http.ResponseWriter, r *http.Request, vsAI bool) {
+	username, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+	var opts gameOptions
+	if !decodeJSON(w, r, &opts) {
+		return
+	}
+	id, err := createGame(username, opts, vsAI)
+	switch err {
+	case nil:
+		writeGameState(w, http.StatusCreated, id, username)
+	case errBadSettings, errNoWord:
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		writeJSONError(w, http.StatusInternalServerError, "could not create game")
+	}
+}
+
+// APICreateGameHandler handles POST /api/v1/games (human vs human; waits for player 2).
+func APICreateGameHandler(w http.ResponseWriter, r *http.Request) {
+	apiCreateGame(w, r, false)
+}
+
+// APICreateAIGameHandler handles POST /api/v1/games/ai (human vs computer; starts immediately).
+func APICreateAIGameHandler(w http.ResponseWriter, r *http.Request) {
+	apiCreateGame(w, r, true)
+}
+
+// APIJoinGameHandler handles POST /api/v1/games/{id}/join.
+func APIJoinGameHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	switch err := joinGame(id, username); err {
+	case nil:
+		writeGameState(w, http.StatusOK, id, username)
+	case store.ErrNotFound:
+		writeJSONError(w, http.StatusNotFound, "game not found")
+	default:
+		writeJSONError(w, http.StatusConflict, err.Error())
+	}
+}
+
+// APIGameStateHandler handles GET /api/v1/games/{id}.
+func APIGameStateHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+	writeGameState(w, http.StatusOK, r.PathValue("id"), username)
+}
+
+// APIGuessHandler handles POST /api/v1/games/{id}/guess with body {"letter": "e"}.
+func APIGuessHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Letter string `json:"letter"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	id := r.PathValue("id")
+	err := playGuess(id, username, body.Letter)
+	var gerr *guessError
+	switch {
+	case err == nil:
+		writeGameState(w, http.StatusOK, id, username)
+	case err == store.ErrNotFound:
+		writeJSONError(w, http.StatusNotFound, "game not found")
+	case errors.As(err, &gerr):
+		writeJSONError(w, gerr.status, gerr.msg)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, "could not register guess")
+	}
+}
+
+// APIHintHandler handles POST /api/v1/games/{id}/hint.
+func APIHintHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+	hint, err := takeHint(r.PathValue("id"), username)
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusOK, map[string]string{"hint": hint})
+	case store.ErrNotFound:
+		writeJSONError(w, http.StatusNotFound, "game not found")
+	case errSetterHint, errSpectatorHint:
+		writeJSONError(w, http.StatusForbidden, err.Error())
+	case errHintGameOver:
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeJSONError(w, http.StatusConflict, "hint unavailable")
+	}
+}
+
+// APILobbyHandler handles GET /api/v1/lobby.
+func APILobbyHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"games": openGames()})
+}
+
+// APIQuickMatchHandler handles POST /api/v1/lobby/quick. A 201 means no compatible game was open,
+// so the caller now hosts a new one; poll its state (or use the WebSocket) until player 2 joins.
+func APIQuickMatchHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+	var opts gameOptions
+	if !decodeJSON(w, r, &opts) {
+		return
+	}
+	id, waiting, err := quickMatch(username, opts)
+	switch {
+	case err == errBadSettings || err == errNoWord:
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, "could not create game")
+	case waiting:
+		writeGameState(w, http.StatusCreated, id, username)
+	default:
+		writeGameState(w, http.StatusOK, id, username)
+	}
+}
+
+// APILeaderboardHandler handles GET /api/v1/leaderboard: the all-time top 10 of each board.
+// /leaderboard?format=json has every board and window, paginated.
+func APILeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	body := map[string]interface{}{}
+	for _, board := range leaderboardBoards {
+		q := leaderboardQuery{Board: board, Window: leaderboardWindows[0], Page: 1, PerPage: leaderboardPerPage}
+		entries, _, err := loadLeaderboard(q)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		key := board.Key
+		if key == "players" {
+			key = "entries" // the name this list had before there were boards
+		}
+		body[key] = apiLeaderboard(entries)
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// One leaderboard row in the JSON API
+type apiLeaderboardEntry struct {
+	Rank        int      `json:"rank"`
+	Player      string   `json:"player"`
+	Rating      int      `json:"rating"`
+	Change      int      `json:"rating_change"` // rating gained in the board's time window
+	Games       int      `json:"games"`         // rated games in the board's pool and window
+	Wins        int      `json:"wins"`
+	Losses      int      `json:"losses"`
+	Draws       int      `json:"draws"`
+	GamesPlayed int      `json:"games_played"` // same as games (kept for clients from before boards)
+	WinRate     *float64 `json:"win_rate"`     // 0..1; null before the first game
+	AvgMisses   *float64 `json:"avg_misses"`   // wrong letters per game; null before the first game
+	BestScore   *int     `json:"best_score"`   // fewest misses in a win; null if none in the window
+}
+
+// Helper: Convert leaderboard rows to their JSON form
+func apiLeaderboard(entries []LeaderboardEntry) []apiLeaderboardEntry {
+	out := []apiLeaderboardEntry{}
+	for _, e := range entries {
+		entry := apiLeaderboardEntry{
+			Rank: e.Rank, Player: e.Player, Rating: e.Rating, Change: e.Change, Games: e.Games,
+			Wins: e.Wins, Losses: e.Losses, Draws: e.Draws, GamesPlayed: e.Games,
+		}
+		if e.Games > 0 {
+			winRate := float64(e.Wins) / float64(e.Games)
+			avgMisses := float64(e.Misses) / float64(e.Games)
+			entry.WinRate, entry.AvgMisses = &winRate, &avgMisses
+		}
+		if e.HasBest {
+			best := e.Best
+			entry.BestScore = &best
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"wordgame/db"
+	"wordgame/session"
+	"wordgame/utils"
+)
+
+// Longest name accepted for an API token
+const maxTokenNameLength = 40
+
+// Helper: The user managing their account. Settings need a real login session:
+// an API token must not be able to mint more tokens or change the account.
+func settingsUser(w http.ResponseWriter, r *http.Request) (session.User, bool) {
+	user, ok := session.FromContext(r.Context())
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return session.User{}, false
+	}
+	if user.APIToken {
+		http.Error(w, "Settings require a login session", http.StatusForbidden)
+		return session.User{}, false
+	}
+	return user, true
+}
+
+// Helper: Show an error on the settings page after redirecting back to it
+func redirectSettingsError(w http.ResponseWriter, r *http.Request, msg string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:  "error",
+		Value: url.QueryEscape(msg),
+		Path:  "/",
+	})
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+// Helper: Render the settings page; newToken is the secret of a just-created token (shown once)
+func renderSettings(w http.ResponseWriter, r *http.Request, user session.User, newToken string) {
+	tokens, err := session.ListTokens(user.ID)
+	if err != nil {
+		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"Tokens":   tokens,
+		"IsBot":    user.IsBot,
+		"NewToken": newToken,
+	}
+
+	// Show (then clear) any error from the last action
+	if errCookie, err := r.Cookie("error"); err == nil {
+		if msg, decodeErr := url.QueryUnescape(errCookie.Value); decodeErr == nil {
+			data["Error"] = msg
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name: "error", Value: "", Path: "/", MaxAge: -1,
+		})
+	}
+
+	utils.RenderPage(w, r, "settings.html", data)
+}
+
+// GET /settings: API tokens and bot flag for the logged-in user
+func SettingsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := settingsUser(w, r)
+	if !ok {
+		return
+	}
+	renderSettings(w, r, user, "")
+}
+
+// POST /settings/tokens: create an API token and show its secret once
+func CreateTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := settingsUser(w, r)
+	if !ok {
+		return
+	}
+
+	r.ParseForm()
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		name = "bot"
+	}
+	if len(name) > maxTokenNameLength {
+		redirectSettingsError(w, r, "Token name is too long.")
+		return
+	}
+	bot := r.FormValue("is_bot") != ""
+	secret, err := session.CreateToken(user.ID, name, bot)
+	if err == session.ErrTooManyTokens {
+		redirectSettingsError(w, r, "You already have the maximum number of tokens. Revoke one first.")
+		return
+	} else if err != nil {
+		redirectSettingsError(w, r, "Could not create the token. Please try again.")
+		return
+	}
+	if bot {
+		user.IsBot = true
+	}
+	// Rendered directly (no redirect) so the secret never ends up in a URL or cookie
+	renderSettings(w, r, user, secret)
+}
+
+// POST /settings/tokens/revoke: delete one of your tokens (bots using it stop working immediately)
+func RevokeTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := settingsUser(w, r)
+	if !ok {
+		return
+	}
+
+	r.ParseForm()
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		redirectSettingsError(w, r, "Unknown token.")
+		return
+	}
+	if err := session.RevokeToken(user.ID, id); err != nil {
+		redirectSettingsError(w, r, "Could not revoke the token. Please try again.")
+		return
+	}
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+// POST /settings/bot: flag the account as a bot; bots get their own leaderboard.
+// The flag is one-way (it can also be set when creating an API token), so a bot can't
+// switch it off to move onto the player board.
+func BotFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := settingsUser(w, r)
+	if !ok {
+		return
+	}
+
+	r.ParseForm()
+	if r.FormValue("is_bot") == "" {
+		if user.IsBot {
+			redirectSettingsError(w, r, "Bot accounts can't be switched back to player accounts.")
+			return
+		}
+		http.Redirect(w, r, "/settings", http.StatusSeeOther)
+		return
+	}
+	if _, err := db.DB.Exec("UPDATE users SET is_bot = 1 WHERE id = ?", user.ID); err != nil {
+		redirectSettingsError(w, r, "Could not update the account. Please try again.")
+		return
+	}
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
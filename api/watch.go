@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"wordgame/logic"
+	"wordgame/models"
+	"wordgame/utils"
+)
+
+// GET /watch/{id}: read-only live view of a game for spectators (no login needed).
+// The page connects to /ws?watch=1, so the secret word stays hidden until the game ends.
+func WatchHandler(w http.ResponseWriter, r *http.Request) {
+	var data map[string]interface{}
+	err := games.View(r.PathValue("id"), func(game *models.Game) error {
+		// Same view spectators get over the WebSocket, plus the fixed game details
+		data = buildGameState(game, "")
+		data["GameID"] = game.ID
+		data["Player1"] = game.Player1
+		data["Player2"] = game.Player2
+		data["CustomWord"] = game.CustomWord
+		data["Category"] = game.Category
+		data["Difficulty"] = game.Difficulty
+		data["Language"] = logic.GameLanguage(game).Name
+		data["Status"] = game.Status
+		data["Spectators"] = spectatorCount(game.ID) + 1 // counting this viewer, about to connect
+		return nil
+	})
+	if err != nil {
+		redirectWithError(w, r, "Game not found.")
+		return
+	}
+	utils.RenderPage(w, r, "watch.html", data)
+}
+
+// GET /watch?game_id=abcd: the "Watch a Game" form on the home page
+func WatchLookupHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("game_id")))
+	if id == "" {
+		redirectWithError(w, r, "Enter a game ID to watch.")
+		return
+	}
+	http.Redirect(w, r, "/watch/"+url.PathEscape(id), http.StatusSeeOther)
+}
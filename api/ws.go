@@ -4,24 +4,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"sync"
-	"wordgame/logic"
+	"wordgame/models"
+	"wordgame/session"
 
 	"github.com/gorilla/websocket"
 )
 
 // Represents a single WebSocket client connection.
-// 'role' distinguishes between player 1/2 (or watcher, if extended).
+// 'role' is the seat the server assigned from the session user (see seatOf), never a client cookie.
+// Spectators (role "") get the masked board but never the secret word before the game ends.
 type Client struct {
-	conn *websocket.Conn
-	role string // "1" or "2"
+	conn    *websocket.Conn
+	role    string     // "1", "2", or "" for a spectator
+	writeMu sync.Mutex // a WebSocket connection allows only one writer at a time
+}
+
+// Helper: Write one text message to the client (safe to call from several goroutines)
+func (c *Client) send(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
 }
 
 var (
 	// Global registry: for every gameID, holds all currently connected clients to that game.
 	clients   = make(map[string][]*Client)
-	clientsMu sync.Mutex // Guards all access to the above map.
+	clientsMu sync.Mutex // Guards all access to the above map (never held while writing to a socket).
 
 	// Allows WebSocket upgrade; insecurely allows *any* origin. Secure in dev, dangerous in prod.
 	upgrader = websocket.Upgrader{
@@ -41,27 +50,39 @@ type WSMessage struct {
 // ----------- WebSocket Handler ----------- //
 
 // HTTP handler: Upgrade connection to WebSocket and process game messages.
-// Path: /ws
+// Path: /ws (add ?watch=1 to connect as a spectator, which needs no login)
 func WebSocketHandler(w http.ResponseWriter, r *http.Request) {
-	// Ensure the user is authenticated (has cookie)
-	userCookie, err := r.Cookie("user")
-	if err != nil || userCookie.Value == "" {
+	watching := r.URL.Query().Get("watch") != ""
+
+	// Ensure the user is authenticated (valid session); spectators may be anonymous
+	user, ok := session.FromContext(r.Context())
+	if !ok && !watching {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	// Require game context
-	gameCookie, err := r.Cookie("game_id")
-	if err != nil || gameCookie.Value == "" {
+	// Require game context: the game_id cookie set by the HTML flow, or ?game_id= for API clients
+	gameID := r.URL.Query().Get("game_id")
+	if gameCookie, err := r.Cookie("game_id"); gameID == "" && err == nil {
+		gameID = gameCookie.Value
+	}
+	if gameID == "" {
 		http.Error(w, "Missing game ID", http.StatusBadRequest)
 		return
 	}
-	// Player role ("1", "2"); fallback "" if missing
-	roleCookie, err := r.Cookie("role")
+
+	// Work out the seat ("1", "2", or "" = spectator) by matching the session user to the game's players.
+	// A player opening the watch page still connects as a spectator.
 	role := ""
-	if err == nil {
-		role = roleCookie.Value
+	err := games.View(gameID, func(game *models.Game) error {
+		if !watching {
+			role = seatOf(game, user.Username)
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
 	}
-	gameID := gameCookie.Value
 
 	// Upgrade HTTP conn to WebSocket (handshake/protocol switch)
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -81,23 +102,17 @@ func WebSocketHandler(w http.ResponseWriter, r *http.Request) {
 	clients[gameID] = append(clients[gameID], client)
 	clientsMu.Unlock()
 
+	// The new client gets the current board (a waiting room may have missed player 2 joining),
+	// and a new spectator changes everyone's spectator count
+	BroadcastToClients(WSMessage{GameID: gameID, Action: "state"})
+
 	// On function exit (client disconnect or handler exit), remove this client.
 	defer func() {
-		clientsMu.Lock()
-		// Remove from slice of clients for this game, if found
-		clientsForGame := clients[gameID]
-		for i, c := range clientsForGame {
-			if c == client {
-				clients[gameID] = append(clientsForGame[:i], clientsForGame[i+1:]...)
-				break
-			}
-		}
-		// If this was the last client for the game, remove entry to prevent memory leak
-		if len(clients[gameID]) == 0 {
-			delete(clients, gameID)
-		}
-		clientsMu.Unlock()
+		removeClient(gameID, client)
 		conn.Close()
+		if role == "" {
+			BroadcastToClients(WSMessage{GameID: gameID, Action: "state"}) // one spectator fewer
+		}
 	}()
 
 	// Main receive loop: wait for client messages
@@ -113,93 +128,132 @@ func WebSocketHandler(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		// Core game logic: handle "guess" action only
+		// Core game logic: handle "guess" action only.
+		// Use the gameID and session user of *this* connection (never trust the payload).
 		if msg.Action == "guess" {
-			// Use gameID/role for *this* connection (safer/more robust than trusting payload)
-			gameID := gameCookie.Value
-			game := games[gameID]
-			if game == nil || game.Status == "finished" {
-				continue // game not found or over
-			}
-
-			// Validate guess: must be a single a-z letter
-			letter := strings.ToLower(strings.TrimSpace(msg.Payload))
-			if len(letter) != 1 || letter < "a" || letter > "z" {
-				continue // ignore invalid
-			}
-
-			// If already guessed, send error message (privately, do NOT broadcast).
-			if game.GuessedLetters[letter] {
-				errMsg := WSMessage{
-					GameID:  game.ID,
-					Action:  "error",
-					Payload: fmt.Sprintf("Letter '%s' has already been guessed.", letter),
-				}
-				data, _ := json.Marshal(errMsg)
-				client.conn.WriteMessage(websocket.TextMessage, data)
+			if watching {
+				sendWSError(client, gameID, "You're watching this game, so you can't guess.")
 				continue
 			}
-
-			// Register the guess (update game state accordingly)
-			logic.RegisterGuess(game, letter)
-
-			// If single-player vs AI and it's now computer's turn: have AI make a move
-			if game.Status != "finished" && game.Player2 == "Computer" && game.PlayerTurn == 2 {
-				aiGuess := logic.AIGuess(game)
-				logic.RegisterGuess(game, aiGuess)
+			err := playGuess(gameID, user.Username, msg.Payload)
+			if gerr, ok := err.(*guessError); ok && gerr != errGameOver {
+				// Tell only this client why the guess was refused (do NOT broadcast)
+				sendWSError(client, gameID, gerr.msg)
 			}
+		}
+	} // end for loop
+}
 
-			// When the game ends (win/loss), update leaderboard IF not a draw
-			if game.Status == "finished" && game.Winner != "Draw" {
-				updateLeaderboard(game.Winner, game.IncorrectGuesses) // (see other files)
-			}
+// Send an error message privately to one client (never broadcast).
+func sendWSError(client *Client, gameID, text string) {
+	data, _ := json.Marshal(WSMessage{
+		GameID:  gameID,
+		Action:  "error",
+		Payload: text,
+	})
+	client.send(data)
+}
 
-			// Broadcast updated state to *all* clients for this game
-			BroadcastToClients(WSMessage{
-				GameID: game.ID,
-				Action: "state",
-			})
+// Send a message privately to one seat's connections for a game (e.g. an achievement unlock).
+func sendToSeat(gameID, seat string, msg WSMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		fmt.Println("Error marshaling WSMessage:", err)
+		return
+	}
+	for _, client := range gameClients(gameID) {
+		if client.role == seat {
+			client.send(data)
 		}
-	} // end for loop
+	}
 }
 
 // Broadcast a message (with game state) to every WebSocket client for the game.
-// Each client gets their own view of state (depends on their role).
+// Each client gets their own view of state (depends on their role). The views are built
+// under the game's lock, and sent after it (and clientsMu) are released, so a slow
+// connection holds up nobody else. Don't call with the game's lock held.
 func BroadcastToClients(msg WSMessage) {
-	clientsMu.Lock()
-	defer clientsMu.Unlock()
-
-	gameID := msg.GameID
-
-	clientsForGame, ok := clients[gameID]
-	if !ok {
+	clientsForGame := gameClients(msg.GameID)
+	if len(clientsForGame) == 0 {
 		return
 	}
 
-	for _, client := range clientsForGame {
-		// Re-build state for each client's view of game (role-sensitive)
-		stateForClient := buildGameState(games[gameID], client.role)
-		msg.State = stateForClient
+	// Players see a live count of who's watching
+	spectators := countSpectators(clientsForGame)
 
-		// JSON encode and write over WebSocket
-		data, err := json.Marshal(msg)
-		if err != nil {
-			fmt.Println("Error marshaling WSMessage:", err)
-			continue
+	// One encoded message per role: every client in a seat sees the same thing
+	data := make(map[string][]byte)
+	err := games.View(msg.GameID, func(game *models.Game) error {
+		for _, client := range clientsForGame {
+			if _, done := data[client.role]; done {
+				continue
+			}
+			stateForClient := buildGameState(game, client.role)
+			stateForClient["Spectators"] = spectators
+			msg.State = stateForClient
+			encoded, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			data[client.role] = encoded
 		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println("Error building WSMessage:", err)
+		return
+	}
 
-		if err := client.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	for _, client := range clientsForGame {
+		if err := client.send(data[client.role]); err != nil {
 			fmt.Println("Error writing WS message, closing conn:", err)
 			client.conn.Close()
 			// Remove this client from the list (prevent leaking dead conns)
-			for i, c := range clientsForGame {
-				if c == client {
-					clients[gameID] = append(clientsForGame[:i], clientsForGame[i+1:]...)
-					break
-				}
-			}
+			removeClient(msg.GameID, client)
+		}
+	}
+}
+
+// Helper: A copy of the clients connected to a game, to write to without holding clientsMu
+func gameClients(gameID string) []*Client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	return append([]*Client(nil), clients[gameID]...)
+}
+
+// Helper: Forget a client's connection to a game (no-op if it's already gone)
+func removeClient(gameID string, client *Client) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	clientsForGame := clients[gameID]
+	for i, c := range clientsForGame {
+		if c == client {
+			clients[gameID] = append(clientsForGame[:i], clientsForGame[i+1:]...)
+			break
+		}
+	}
+	// If this was the last client for the game, remove entry to prevent memory leak
+	if len(clients[gameID]) == 0 {
+		delete(clients, gameID)
+	}
+}
+
+// Helper: Number of spectator connections among a game's clients; caller holds clientsMu
+func countSpectators(clientsForGame []*Client) int {
+	n := 0
+	for _, client := range clientsForGame {
+		if client.role == "" {
+			n++
 		}
 	}
+	return n
+}
+
+// Helper: Number of spectators currently connected to a game
+func spectatorCount(gameID string) int {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	return countSpectators(clients[gameID])
 }
 
 // Goroutine starter: listens on global wsBroadcast chan and rebroadcasts messages as needed.
@@ -85,7 +85,8 @@ func InitDB() {
                 created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
                 last_login TIMESTAMP
             );`,
-			// LEADERBOARD: one row per player (by user ID), win stats, performance stats
+			// LEADERBOARD: one row per player (by user ID) with the stats saved games can't give,
+			// e.g. wins from before games were saved (the boards themselves use rating_history)
 			`CREATE TABLE IF NOT EXISTS leaderboard (
                 player INTEGER PRIMARY KEY,
                 wins INTEGER DEFAULT 0,
@@ -105,11 +106,93 @@ func InitDB() {
                 created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
                 finished_at TIMESTAMP,
                 FOREIGN KEY(player_id) REFERENCES users(id) ON DELETE CASCADE
+            );`,
+			// DAILY_RESULTS: one attempt per user per day at the word of the day, guesses stored in order ("a,e,r")
+			`CREATE TABLE IF NOT EXISTS daily_results (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                user_id INTEGER NOT NULL,
+                puzzle_date TEXT NOT NULL,
+                word TEXT NOT NULL,
+                guesses TEXT DEFAULT '',
+                misses INTEGER DEFAULT 0,
+                status TEXT DEFAULT 'in_progress' CHECK(status IN ('in_progress', 'won', 'lost')),
+                started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                finished_at TIMESTAMP,
+                UNIQUE(user_id, puzzle_date),
+                FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+            );`,
+			// LIVE_GAMES: JSON snapshot of every unfinished in-memory game, keyed by its 4-letter ID,
+			// so games survive a server restart (see store.SQLiteStore)
+			`CREATE TABLE IF NOT EXISTS live_games (
+                code TEXT PRIMARY KEY,
+                state TEXT NOT NULL,
+                updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+            );`,
+			// SESSIONS: server-side login sessions; the cookie holds a random token, only its SHA-256 is stored
+			`CREATE TABLE IF NOT EXISTS sessions (
+                token_hash TEXT PRIMARY KEY,
+                user_id INTEGER NOT NULL,
+                created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                expires_at INTEGER NOT NULL,
+                FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+            );`,
+			// API_TOKENS: personal tokens for bots/scripts (sent as "Authorization: Bearer ..."), stored hashed
+			`CREATE TABLE IF NOT EXISTS api_tokens (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                user_id INTEGER NOT NULL,
+                name TEXT NOT NULL,
+                token_hash TEXT UNIQUE NOT NULL,
+                created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                last_used_at TIMESTAMP,
+                FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+            );`,
+			// RATINGS: Elo rating per user and pool ("versus" = against people, "ai" = against the computer)
+			`CREATE TABLE IF NOT EXISTS ratings (
+                user_id INTEGER NOT NULL,
+                pool TEXT NOT NULL CHECK(pool IN ('versus', 'ai')),
+                rating INTEGER NOT NULL DEFAULT 1200,
+                games INTEGER NOT NULL DEFAULT 0,
+                updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                PRIMARY KEY (user_id, pool),
+                FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+            );`,
+			// RATING_HISTORY: one row per player per rated game, with the rating before and after
+			`CREATE TABLE IF NOT EXISTS rating_history (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                user_id INTEGER NOT NULL,
+                pool TEXT NOT NULL CHECK(pool IN ('versus', 'ai')),
+                game_id INTEGER REFERENCES games(id) ON DELETE SET NULL,
+                opponent TEXT NOT NULL,
+                result TEXT NOT NULL CHECK(result IN ('win', 'loss', 'draw')),
+                old_rating INTEGER NOT NULL,
+                new_rating INTEGER NOT NULL,
+                created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+            );`,
+			// ACHIEVEMENTS: badges players have unlocked (keys from package achievements), once each
+			`CREATE TABLE IF NOT EXISTS achievements (
+                user_id INTEGER NOT NULL,
+                achievement TEXT NOT NULL,
+                game_id INTEGER REFERENCES games(id) ON DELETE SET NULL,
+                unlocked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                PRIMARY KEY (user_id, achievement),
+                FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+            );`,
+			// SERVER_SECRETS: secrets the server generates for itself when none is configured (e.g. "daily")
+			`CREATE TABLE IF NOT EXISTS server_secrets (
+                name TEXT PRIMARY KEY,
+                value TEXT NOT NULL,
+                created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
             );`,
 			// Indexes to accelerate common queries (stats by player, lookup by username, filtering by game state)
 			`CREATE INDEX IF NOT EXISTS idx_games_player ON games(player_id);`,
 			`CREATE INDEX IF NOT EXISTS idx_games_status ON games(status);`,
 			`CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);`,
+			`CREATE INDEX IF NOT EXISTS idx_daily_date ON daily_results(puzzle_date);`,
+			`CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);`,
+			`CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens(user_id);`,
+			`CREATE INDEX IF NOT EXISTS idx_ratings_pool ON ratings(pool, rating);`,
+			`CREATE INDEX IF NOT EXISTS idx_rating_history_user ON rating_history(user_id, pool);`,
 		}
 
 		// Create all tables and indexes. If any fail, crash immediately.
@@ -119,6 +202,60 @@ func InitDB() {
 			}
 		}
 
+		// Columns added to the games table after the original schema (full game records: both
+		// players, winner, guess order, hints). Applied to new and existing databases alike.
+		gameColumns := []struct{ name, definition string }{
+			{"game_code", "TEXT DEFAULT ''"},                                  // 4-letter in-memory game ID
+			{"player2_id", "INTEGER REFERENCES users(id) ON DELETE SET NULL"}, // NULL for AI / deleted users
+			{"player2_name", "TEXT DEFAULT ''"},                               // e.g. "Computer"
+			{"winner", "TEXT DEFAULT ''"},                                     // username, "Draw" or ''
+			{"mode", "TEXT DEFAULT 'versus'"},                                 // "versus", "ai", "custom"
+			{"incorrect_guesses", "INTEGER DEFAULT 0"},                        // misses when the game ended
+			{"max_incorrect", "INTEGER DEFAULT 0"},                            // misses allowed
+			{"hint_used", "INTEGER DEFAULT 0"},                                // 1 if the hint was revealed
+			{"category", "TEXT DEFAULT ''"},                                   // word category key
+			{"language", "TEXT DEFAULT 'en'"},                                 // word language code
+			{"difficulty", "TEXT DEFAULT ''"},                                 // easy / medium / hard
+			{"hint_taker", "TEXT DEFAULT ''"},                                 // username of whoever revealed the hint ('' = none, or not recorded)
+			{"accent_insensitive", "INTEGER DEFAULT 0"},                       // 1 if guessing "a" also revealed "á", "ã", ... (0 on older games)
+		}
+		for _, col := range gameColumns {
+			if err := addColumnIfMissing("games", col.name, col.definition); err != nil {
+				log.Fatalf("Failed to migrate games.%s: %v", col.name, err)
+			}
+		}
+		// Games in which the player took the hint (older games don't record who took it, so this
+		// can't be worked out from the games table)
+		if err := addColumnIfMissing("leaderboard", "hints_used", "INTEGER DEFAULT 0"); err != nil {
+			log.Fatalf("Failed to migrate leaderboard.hints_used: %v", err)
+		}
+		// Losses, draws and misses are computed from rating_history now; the leaderboard table's own
+		// copies would only drift apart from them. wins and best_score stay: they go back to before
+		// games were saved, so nothing else holds those older results.
+		for _, column := range []string{"losses", "draws", "total_misses"} {
+			if err := dropColumnIfPresent("leaderboard", column); err != nil {
+				log.Fatalf("Failed to drop leaderboard.%s: %v", column, err)
+			}
+		}
+		// Misses per rated game, for the weekly/monthly leaderboards (NULL on rows saved before this;
+		// handlers.BackfillRatings fills them in from the games table)
+		if err := addColumnIfMissing("rating_history", "misses", "INTEGER"); err != nil {
+			log.Fatalf("Failed to migrate rating_history.misses: %v", err)
+		}
+		// Bot accounts (flagged by their owners on /settings) are listed separately on the leaderboard
+		if err := addColumnIfMissing("users", "is_bot", "INTEGER DEFAULT 0"); err != nil {
+			log.Fatalf("Failed to migrate users.is_bot: %v", err)
+		}
+		for _, index := range []string{
+			`CREATE INDEX IF NOT EXISTS idx_games_player2 ON games(player2_id);`,
+			`CREATE INDEX IF NOT EXISTS idx_games_finished ON games(finished_at);`,
+			`CREATE INDEX IF NOT EXISTS idx_rating_history_time ON rating_history(pool, created_at);`,
+		} {
+			if _, err := DB.Exec(index); err != nil {
+				log.Fatalf("Failed to create index: %v\nQuery: %s", err, index)
+			}
+		}
+
 		// Post-migration safety check: foreign key integrity
 		if _, err := DB.Exec("PRAGMA foreign_key_check;"); err != nil {
 			log.Printf("Warning: foreign key constraint issue: %v", err)
@@ -128,6 +265,51 @@ func InitDB() {
 	})
 }
 
+// addColumnIfMissing adds a column to an existing table unless it is already there.
+// SQLite has no "ADD COLUMN IF NOT EXISTS", so the current columns are checked first (see hasColumn).
+func addColumnIfMissing(table, column, definition string) error {
+	exists, err := hasColumn(table, column)
+	if err != nil || exists {
+		return err // already migrated (or couldn't tell)
+	}
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, definition))
+	return err
+}
+
+// dropColumnIfPresent removes a column from an existing table if it is still there.
+func dropColumnIfPresent(table, column string) error {
+	exists, err := hasColumn(table, column)
+	if err != nil || !exists {
+		return err // already migrated (or couldn't tell)
+	}
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, column))
+	return err
+}
+
+// hasColumn reports whether a table has a column, from PRAGMA table_info.
+func hasColumn(table, column string) (bool, error) {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // CloseDB cleanly closes the global DB connection when shutting down.
 // Performs a final WAL checkpoint to persist all writes.
 func CloseDB() error {
@@ -0,0 +1,250 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"wordgame/models"
+)
+
+// -------- AI STRATEGIES --------
+
+// AIStrategy decides the computer's next letter in a game against the AI.
+// A strategy only looks at what a player could see (the masked word, the letters guessed so far,
+// the category) and returns a letter of the game's alphabet that hasn't been guessed yet, or an
+// error if it can't come up with one.
+type AIStrategy interface {
+	Guess(game *models.Game) (string, error)
+}
+
+// One selectable AI opponent, as offered when creating a game against the computer
+type AIStrategyInfo struct {
+	Key         string // stored on the game (models.Game.AIStrategy) and sent by forms / the API
+	Name        string
+	Description string
+	Strategy    AIStrategy
+}
+
+// DefaultAIStrategy is used for games that don't pick one (and for games created before strategies existed).
+const DefaultAIStrategy = "llm"
+
+var aiStrategies = []AIStrategyInfo{
+	{Key: "llm", Name: "Gemini", Description: "asks an LLM; plays like Dictionary when it's unavailable", Strategy: LLMStrategy{}},
+	{Key: "solver", Name: "Solver", Description: "picks the letter that tells it the most about the word", Strategy: SolverStrategy{}},
+	{Key: "pattern", Name: "Dictionary", Description: "picks the letter most likely to be in the word", Strategy: PatternStrategy{}},
+	{Key: "frequency", Name: "Frequency", Description: "the language's most common letters first", Strategy: FrequencyStrategy{}},
+	{Key: "random", Name: "Random", Description: "any letter at all (easy)", Strategy: RandomStrategy{}},
+}
+
+// AIStrategies lists every selectable strategy, default first.
+func AIStrategies() []AIStrategyInfo {
+	return aiStrategies
+}
+
+// AIStrategyByKey looks up a strategy ("" = DefaultAIStrategy).
+func AIStrategyByKey(key string) (AIStrategyInfo, error) {
+	if key == "" {
+		key = DefaultAIStrategy
+	}
+	for _, s := range aiStrategies {
+		if s.Key == key {
+			return s, nil
+		}
+	}
+	return AIStrategyInfo{}, fmt.Errorf("unknown AI strategy %q", key)
+}
+
+// Returns the next letter for the AI to guess, using the game's strategy.
+// If the strategy fails or comes back with an invalid or repeated letter, falls back to the
+// dictionary solver (or, if no dictionary word fits the board, to Frequency).
+func AIGuess(game *models.Game) string {
+	info, err := AIStrategyByKey(game.AIStrategy)
+	if err != nil {
+		fmt.Println("AI strategy error:", err)
+		info, _ = AIStrategyByKey(DefaultAIStrategy)
+	}
+
+	guess, err := info.Strategy.Guess(game)
+	if err == nil {
+		if guess, err = NormalizeGuess(game, guess); err == nil && !game.GuessedLetters[guess] {
+			return guess
+		}
+		fmt.Printf("%s AI guessed an invalid or duplicate letter: %q\n", info.Name, guess)
+	} else {
+		fmt.Printf(" %s AI error: %v\n", info.Name, err)
+	}
+
+	// Fallback: the dictionary solver, then frequency-based guessing (when no dictionary word fits)
+	fmt.Println(" Using fallback AI")
+	for _, fallback := range []AIStrategy{PatternStrategy{}, FrequencyStrategy{}} {
+		if guess, err := fallback.Guess(game); err == nil {
+			fmt.Println("Fallback guess:", guess)
+			return guess
+		}
+	}
+
+	// Very unlikely: if even frequency letters exhausted, random guess as last resort
+	alphabet := GuessableAlphabet(game)
+	rand.Seed(time.Now().UnixNano())
+	letter := string(alphabet[rand.Intn(len(alphabet))])
+	fmt.Println("Random guess:", letter)
+	return letter
+}
+
+// Helper: The game's guessable letters that haven't been guessed yet, most frequent first
+func unguessedLetters(game *models.Game) []string {
+	var letters []string
+	for _, l := range GuessableAlphabet(game) {
+		if !game.GuessedLetters[string(l)] {
+			letters = append(letters, string(l))
+		}
+	}
+	return letters
+}
+
+// -------- FREQUENCY --------
+
+// FrequencyStrategy guesses the most common letter of the game's language that hasn't been tried,
+// ignoring the board entirely.
+type FrequencyStrategy struct{}
+
+func (FrequencyStrategy) Guess(game *models.Game) (string, error) {
+	letters := unguessedLetters(game)
+	if len(letters) == 0 {
+		return "", fmt.Errorf("every letter has been guessed")
+	}
+	return letters[0], nil
+}
+
+// -------- RANDOM --------
+
+// RandomStrategy is the deliberately weak opponent: any untried letter, common or not.
+type RandomStrategy struct{}
+
+func (RandomStrategy) Guess(game *models.Game) (string, error) {
+	letters := unguessedLetters(game)
+	if len(letters) == 0 {
+		return "", fmt.Errorf("every letter has been guessed")
+	}
+	return letters[rand.Intn(len(letters))], nil
+}
+
+// -------- DICTIONARY (see solver.go) --------
+
+// PatternStrategy only considers the dictionary words that fit the board and guesses the untried
+// letter found in the most of them (the fewest expected misses).
+// Fails if no dictionary word fits (e.g. a host's custom word).
+type PatternStrategy struct{}
+
+func (PatternStrategy) Guess(game *models.Game) (string, error) {
+	return NewSolver(game).BestLetter(MinMisses)
+}
+
+// SolverStrategy is like PatternStrategy, but guesses the letter that narrows the candidates down
+// the most (the highest expected information) rather than the safest one.
+type SolverStrategy struct{}
+
+func (SolverStrategy) Guess(game *models.Game) (string, error) {
+	return NewSolver(game).BestLetter(MaxInformation)
+}
+
+// -------- LLM (GEMINI) --------
+
+// LLMStrategy asks Gemini for a letter, describing the board in a prompt.
+// Needs GEMINI_API_KEY; without it every guess fails (and AIGuess falls back to the solver).
+type LLMStrategy struct{}
+
+func (LLMStrategy) Guess(game *models.Game) (string, error) {
+	lang := GameLanguage(game)
+
+	// Prepare prompt summarizing game state for the AI chatbot.
+	prompt := fmt.Sprintf(
+		"You're playing Hangman in %s. Known word (underscores are hidden letters): '%s'. Letters guessed: [%v]. Suggest ONE new lowercase letter from [%s] that has not been guessed.",
+		lang.Name,
+		compactPattern(game),
+		guessedLettersList(game.GuessedLetters),
+		string(GuessableAlphabet(game)),
+	)
+	// Themed games: the category is a free clue, so share it with the AI too.
+	if game.Category != "" {
+		prompt += fmt.Sprintf(" The word belongs to the category '%s'.", game.Category)
+	}
+
+	// Call Gemini AI API with prompt
+	aiGuess, err := getAIGuessFromGemini(prompt)
+	if err != nil {
+		return "", err
+	}
+	aiGuess = strings.ToLower(strings.TrimSpace(aiGuess))
+	if aiGuess == "" {
+		return "", fmt.Errorf("gemini returned an empty guess")
+	}
+	fmt.Println(" Gemini guess:", string([]rune(aiGuess)[0]))
+	return string([]rune(aiGuess)[0]), nil
+}
+
+// Returns a one-letter Hangman guess from Gemini (calls Google Generative Language API) or an error.
+func getAIGuessFromGemini(prompt string) (string, error) {
+	endpoint := "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent"
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return "", fmt.Errorf("missing GEMINI_API_KEY")
+	}
+
+	// Gemini JSON structure
+	reqBody := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"parts": []map[string]string{
+					{"text": prompt},
+				},
+			},
+		},
+	}
+	data, _ := json.Marshal(reqBody)
+
+	// Build POST request
+	req, err := http.NewRequest("POST", endpoint+"?key="+apiKey, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		// If API returns error, propagate error plus Gemini debug text
+		return "", fmt.Errorf("gemini api status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Minimal (but robust) structure for Gemini JSON response
+	var parsed struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", err
+	}
+	if len(parsed.Candidates) == 0 || len(parsed.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("gemini responded but no text")
+	}
+
+	return parsed.Candidates[0].Content.Parts[0].Text, nil
+}
@@ -1,16 +1,13 @@
 package logic
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"math/rand"
-	"net/http"
-	"os"
 	"strings"
 	"time"
+	"unicode"
 	"wordgame/models"
+	"wordgame/words"
 )
 
 const AIPlayerName = "Computer"
@@ -28,10 +25,13 @@ func GetHint(game *models.Game) (string, error) {
 	// Collect all letters in the word that have NOT been guessed yet.
 	unguessed := []string{}
 	for _, c := range game.Word {
-		letter := string(c)
-		if !game.GuessedLetters[letter] {
-			unguessed = append(unguessed, letter)
+		if isRevealed(game, c) {
+			continue
 		}
+		if game.AccentInsensitive {
+			c = words.FoldAccent(c) // suggest the letter the player actually has to type
+		}
+		unguessed = append(unguessed, string(c))
 	}
 
 	// No unguessed letters left? Edge case: don't suggest if word is fully revealed.
@@ -47,133 +47,21 @@ func GetHint(game *models.Game) (string, error) {
 	game.HintText = fmt.Sprintf("Try the letter '%s'.", hintLetter)
 
 	// Update display word (for consistency in UI after hint; may not actually reveal the letter instantly).
-	newDisplay := ""
-	for _, c := range game.Word {
-		if game.GuessedLetters[string(c)] {
-			newDisplay += string(c) + " "
-		} else {
-			newDisplay += "_ "
-		}
-	}
-	game.DisplayWord = newDisplay
+	game.DisplayWord = MaskWord(game)
 
 	return game.HintText, nil
 }
 
-// -------- AI GUESSING LOGIC --------
-
-// Returns the next letter for the AI to guess, using Gemini AI or falling back to English letter frequency.
-func AIGuess(game *models.Game) string {
-	// Prepare prompt summarizing game state for the AI chatbot.
-	prompt := fmt.Sprintf(
-		"You're playing Hangman. Known word: '%s'. Letters guessed: [%v]. Suggest ONE new lowercase letter (a-z) that has not been guessed.",
-		strings.ReplaceAll(game.DisplayWord, " ", ""),
-		guessedLettersList(game.GuessedLetters),
-	)
-
-	// Call Gemini AI API with prompt
-	aiGuess, err := getAIGuessFromGemini(prompt)
-	if err != nil {
-		fmt.Println(" Gemini error:", err)
-	} else {
-		aiGuess = strings.ToLower(strings.TrimSpace(aiGuess))
-		if len(aiGuess) > 0 {
-			guess := string([]rune(aiGuess)[0])
-			if guess >= "a" && guess <= "z" && !game.GuessedLetters[guess] {
-				fmt.Println(" Gemini guess used:", guess)
-				return guess
-			}
-			fmt.Println("Gemini guessed invalid or duplicate letter:", guess)
-		}
-	}
-
-	// Fallback: frequency-based guessing if Gemini fails or gives nonsense
-	fmt.Println(" Using fallback AI")
-	frequency := "etaoinshrdlcumwfgypbvkjxqz" // most common English letters, in order
-	for _, l := range frequency {
-		letter := string(l)
-		if !game.GuessedLetters[letter] {
-			fmt.Println("Fallback guess:", letter)
-			return letter
-		}
-	}
-
-	// Very unlikely: if even frequency letters exhausted, random guess as last resort
-	rand.Seed(time.Now().UnixNano())
-	letter := string('a' + rune(rand.Intn(26)))
-	fmt.Println("Random guess:", letter)
-	return letter
-}
-
-// -------- GEMINI API INTEGRATION --------
-
-// Returns a one-letter Hangman guess from Gemini (calls Google Generative Language API) or an error.
-func getAIGuessFromGemini(prompt string) (string, error) {
-	endpoint := "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent"
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("missing GEMINI_API_KEY")
-	}
-
-	// Gemini JSON structure
-	reqBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]string{
-					{"text": prompt},
-				},
-			},
-		},
-	}
-	data, _ := json.Marshal(reqBody)
-
-	// Build POST request
-	req, err := http.NewRequest("POST", endpoint+"?key="+apiKey, bytes.NewReader(data))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 20 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		// If API returns error, propagate error plus Gemini debug text
-		return "", fmt.Errorf("gemini api status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Minimal (but robust) structure for Gemini JSON response
-	var parsed struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-	}
-	if err := json.Unmarshal(body, &parsed); err != nil {
-		return "", err
-	}
-	if len(parsed.Candidates) == 0 || len(parsed.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("gemini responded but no text")
-	}
-
-	return parsed.Candidates[0].Content.Parts[0].Text, nil
-}
-
 // -------- GAMEPLAY LOGIC --------
 
 // Registers a player's guess (letter) into the game state.
 // Updates guessed list, display word, guesses counter, turn info, and winner.
-// Returns error if letter has already been guessed.
+// Returns error if the letter isn't in the game's alphabet or has already been guessed.
 func RegisterGuess(game *models.Game, letter string) error {
-	letter = strings.ToLower(letter) // Standardize letter
+	letter, err := NormalizeGuess(game, letter) // Standardize letter (lowercase, accent folding)
+	if err != nil {
+		return err
+	}
 
 	if game.GuessedLetters[letter] {
 		return fmt.Errorf("letter '%s' has already been guessed", letter)
@@ -183,23 +71,15 @@ func RegisterGuess(game *models.Game, letter string) error {
 	game.GuessedLetters[letter] = true
 	game.GuessHistory = append(game.GuessHistory, letter)
 	// Rebuild the display word, with spaces separating revealed letters and underscores for missing ones
-	newDisplay := ""
-	for _, c := range game.Word {
-		if game.GuessedLetters[string(c)] {
-			newDisplay += string(c) + " "
-		} else {
-			newDisplay += "_ "
-		}
-	}
-	game.DisplayWord = newDisplay
+	game.DisplayWord = MaskWord(game)
 
 	// If guess was wrong, increment incorrect guess count
-	if !strings.Contains(game.Word, letter) {
+	if !InWord(game, letter) {
 		game.IncorrectGuesses++
 	}
 
-	// Winning condition: no underscores left? (fully revealed)
-	if !strings.Contains(game.DisplayWord, "_") {
+	// Winning condition: every letter revealed? (spaces/punctuation don't count)
+	if IsSolved(game) {
 		game.Status = "finished"
 		if game.PlayerTurn == 1 {
 			game.Winner = game.Player1
@@ -209,9 +89,15 @@ func RegisterGuess(game *models.Game, letter string) error {
 	} else if game.IncorrectGuesses >= game.MaxIncorrectGuesses {
 		// Losing condition: too many wrong guesses
 		game.Status = "finished"
-		game.Winner = "Draw"
-	} else {
-		// Otherwise, switch turns
+		if game.CustomWord {
+			game.Winner = game.Player1 // the host's word stumped the guesser
+		} else if game.Daily {
+			game.Winner = "" // solo puzzle: nobody to award it to
+		} else {
+			game.Winner = "Draw"
+		}
+	} else if !game.CustomWord && !game.Daily {
+		// Otherwise, switch turns (custom-word and daily games: the same player keeps guessing)
 		if game.PlayerTurn == 1 {
 			game.PlayerTurn = 2
 		} else {
@@ -222,6 +108,115 @@ func RegisterGuess(game *models.Game, letter string) error {
 	return nil
 }
 
+// -------- LETTER MATCHING --------
+
+// Returns the game's language (English if unset or unknown).
+func GameLanguage(game *models.Game) *words.Language {
+	lang, err := words.LanguageByCode(game.Language)
+	if err != nil {
+		lang, _ = words.LanguageByCode(words.DefaultLanguage)
+	}
+	return lang
+}
+
+// Returns the letters a player may guess in this game, most frequent first.
+// Accent-insensitive games only offer base letters (accented ones are folded anyway).
+func GuessableAlphabet(game *models.Game) []rune {
+	letters := []rune{}
+	for _, c := range GameLanguage(game).Alphabet() {
+		if !game.AccentInsensitive || words.FoldAccent(c) == c {
+			letters = append(letters, c)
+		}
+	}
+	return letters
+}
+
+// Lowercases raw input and checks it is a single letter of the game's alphabet.
+// In accent-insensitive games the letter is folded to its base form ("Á" -> "a").
+func NormalizeGuess(game *models.Game, input string) (string, error) {
+	runes := []rune(strings.ToLower(strings.TrimSpace(input)))
+	if len(runes) != 1 || !GameLanguage(game).HasLetter(runes[0]) {
+		return "", fmt.Errorf("'%s' is not a valid letter", input)
+	}
+	c := runes[0]
+	if game.AccentInsensitive {
+		c = words.FoldAccent(c)
+	}
+	return string(c), nil
+}
+
+// Reports whether guessing letter reveals at least one character of the secret word.
+func InWord(game *models.Game, letter string) bool {
+	for _, c := range game.Word {
+		if IsGuessable(c) && matchesGuess(game, c, letter) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether character c of the secret word is revealed by guessing letter.
+func matchesGuess(game *models.Game, c rune, letter string) bool {
+	if string(c) == letter {
+		return true
+	}
+	return game.AccentInsensitive && string(words.FoldAccent(c)) == letter
+}
+
+// Reports whether character c of the secret word is currently shown.
+func isRevealed(game *models.Game, c rune) bool {
+	if !IsGuessable(c) || game.GuessedLetters[string(c)] {
+		return true
+	}
+	return game.AccentInsensitive && game.GuessedLetters[string(words.FoldAccent(c))]
+}
+
+// -------- DISPLAY HELPERS --------
+
+// Reports whether a character of the secret word has to be guessed.
+// Spaces, hyphens, apostrophes etc. in phrases are shown from the start.
+func IsGuessable(c rune) bool {
+	return unicode.IsLetter(c)
+}
+
+// Builds the masked DisplayWord: revealed letters and non-letters as-is, "_" for hidden letters,
+// each followed by a space. Word boundaries in phrases become a wider gap: "_ _ _   _ _ _ _ _ ".
+func MaskWord(game *models.Game) string {
+	var b strings.Builder
+	for _, c := range game.Word {
+		if isRevealed(game, c) {
+			b.WriteString(string(c) + " ")
+		} else {
+			b.WriteString("_ ")
+		}
+	}
+	return b.String()
+}
+
+// Reports whether every guessable letter of the secret word has been revealed.
+func IsSolved(game *models.Game) bool {
+	for _, c := range game.Word {
+		if !isRevealed(game, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the word pattern without display padding, keeping word breaks: "i_e cr_am".
+// Used for AI prompts, where the spaced-out DisplayWord would hide phrase boundaries.
+func compactPattern(game *models.Game) string {
+	var b strings.Builder
+	for _, c := range game.Word {
+		if isRevealed(game, c) {
+			b.WriteRune(c)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
 // Converts a map[string]bool of guessed letters to a comma-separated "a, b, c" string.
 // Used for creating human-readable guess history for AI prompts, debugging, etc.
 func guessedLettersList(m map[string]bool) string {
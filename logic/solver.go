@@ -0,0 +1,182 @@
+package logic
+
+import (
+	"fmt"
+	"math"
+	"wordgame/models"
+	"wordgame/words"
+)
+
+// -------- SOLVER --------
+//
+// The solver keeps the set of dictionary words that are still possible answers: the words with
+// the board's shape whose revealed letters are in place and whose hidden squares don't hold any
+// letter already guessed (so wrong letters rule out every word containing them). From that set it
+// scores each untried letter and can pick the best one. It only uses what players can see, never
+// the secret word itself, so the computer opponent can use it and the count can be shown to anyone.
+
+// SolverGoal is what the solver optimises when choosing a letter.
+type SolverGoal int
+
+const (
+	// MaxInformation picks the letter whose outcome (where it shows up, if anywhere) narrows the
+	// candidates down the most on average: the highest entropy in bits.
+	MaxInformation SolverGoal = iota
+	// MinMisses picks the letter most likely to be in the word, i.e. the fewest expected misses.
+	MinMisses
+)
+
+// Solver holds the candidate words for one game's board at the moment it was created.
+type Solver struct {
+	game       *models.Game
+	Candidates []string // dictionary words still consistent with the board
+}
+
+// LetterScore is how one untried letter would do against the current candidates.
+type LetterScore struct {
+	Letter      string
+	Hits        int     // candidates containing the letter
+	MissChance  float64 // share of candidates without it (0..1)
+	Information float64 // expected information from guessing it, in bits
+}
+
+// NewSolver collects the candidates for the game's board as it stands.
+// The caller holds the game's lock (or owns the game).
+func NewSolver(game *models.Game) *Solver {
+	pattern := []rune(compactPattern(game))
+	letters := 0
+	for _, c := range pattern {
+		if c == '_' || IsGuessable(c) {
+			letters++
+		}
+	}
+
+	s := &Solver{game: game}
+	for _, word := range solverDictionary(game).Words(letters) {
+		if fitsPattern(game, []rune(word), pattern) {
+			s.Candidates = append(s.Candidates, word)
+		}
+	}
+	return s
+}
+
+// Helper: The word list the secret word was picked from: the category's list for themed games
+// (most of those words and phrases aren't in the language dictionary), else the language's.
+// Custom words and words from WORD_SOURCE=file/api may be in neither (see CountCandidates).
+func solverDictionary(game *models.Game) *words.Dictionary {
+	if game.Category != "" {
+		if dict, err := words.CategoryDictionary(game.Category); err == nil {
+			return dict
+		}
+	}
+	return GameLanguage(game).Dictionary()
+}
+
+// Count is how many dictionary words are still possible (0 if the word isn't in the dictionary).
+func (s *Solver) Count() int {
+	return len(s.Candidates)
+}
+
+// CountCandidates is the candidate count shown to players, or 0 when the secret word isn't in the
+// solver's word list (custom words, WORD_SOURCE=file/api): the count would only be of other words.
+// Caller holds the game's lock.
+func CountCandidates(game *models.Game) int {
+	if !solverDictionary(game).Contains(game.Word) {
+		return 0
+	}
+	return NewSolver(game).Count()
+}
+
+// Scores rates every untried letter of the game's alphabet, most frequent letter first.
+func (s *Solver) Scores() []LetterScore {
+	var scores []LetterScore
+	n := float64(len(s.Candidates))
+	for _, letter := range unguessedLetters(s.game) {
+		score := LetterScore{Letter: letter}
+		// Group the candidates by where the letter would appear ("" = nowhere, a miss)
+		outcomes := make(map[string]int)
+		for _, word := range s.Candidates {
+			positions := letterPositions(s.game, word, letter)
+			if positions != "" {
+				score.Hits++
+			}
+			outcomes[positions]++
+		}
+		if n > 0 {
+			score.MissChance = 1 - float64(score.Hits)/n
+			for _, count := range outcomes {
+				p := float64(count) / n
+				score.Information -= p * math.Log2(p)
+			}
+		}
+		scores = append(scores, score)
+	}
+	return scores
+}
+
+// BestLetter returns the untried letter that does best for goal, or an error if no candidates
+// are left (e.g. a custom word that isn't in the dictionary). Ties go to the more common letter.
+func (s *Solver) BestLetter(goal SolverGoal) (string, error) {
+	if len(s.Candidates) == 0 {
+		return "", fmt.Errorf("no dictionary word fits %q", compactPattern(s.game))
+	}
+	var best *LetterScore
+	scores := s.Scores()
+	for i := range scores {
+		if scores[i].Hits == 0 {
+			continue // a sure miss is never worth it
+		}
+		if best == nil || better(scores[i], *best, goal) {
+			best = &scores[i]
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no untried letter appears in the %d candidate(s)", len(s.Candidates))
+	}
+	return best.Letter, nil
+}
+
+// Reports whether a scores strictly better than b for goal (the other measure breaks ties).
+func better(a, b LetterScore, goal SolverGoal) bool {
+	const epsilon = 1e-9
+	infoDiff := a.Information - b.Information
+	if goal == MinMisses {
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		return infoDiff > epsilon
+	}
+	if math.Abs(infoDiff) > epsilon {
+		return infoDiff > 0
+	}
+	return a.Hits > b.Hits
+}
+
+// Reports whether a dictionary word could be the secret word, given the compact pattern.
+func fitsPattern(game *models.Game, word, pattern []rune) bool {
+	if len(word) != len(pattern) {
+		return false
+	}
+	for i, c := range word {
+		if pattern[i] == '_' {
+			// A hidden square holds a letter nobody has guessed (it would be showing otherwise)
+			if !IsGuessable(c) || isRevealed(game, c) {
+				return false
+			}
+		} else if c != pattern[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns where guessing letter would reveal characters of word, e.g. "0,3" ("" = nowhere).
+func letterPositions(game *models.Game, word, letter string) string {
+	positions := ""
+	for i, c := range []rune(word) {
+		if IsGuessable(c) && matchesGuess(game, c, letter) {
+			positions += fmt.Sprintf("%d,", i)
+		}
+	}
+	return positions
+}
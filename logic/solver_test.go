@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"testing"
+	"wordgame/models"
+	"wordgame/words"
+)
+
+// Helper: A fresh game for word (category "" = a plain dictionary word)
+func solverGame(word, category string) *models.Game {
+	game := &models.Game{
+		Word:                word,
+		Category:            category,
+		Language:            "en",
+		GuessedLetters:      make(map[string]bool),
+		MaxIncorrectGuesses: 26,
+		PlayerTurn:          1,
+		Status:              "in_progress",
+	}
+	game.DisplayWord = MaskWord(game)
+	return game
+}
+
+// Helper: Whether the secret word is still among the solver's candidates
+func hasCandidate(s *Solver, word string) bool {
+	for _, c := range s.Candidates {
+		if c == word {
+			return true
+		}
+	}
+	return false
+}
+
+// Themed games draw from the category's list, so the answer is always a candidate, phrases included,
+// and the solver can play the word out without ever losing it.
+func TestSolverCategoryWords(t *testing.T) {
+	for _, category := range words.Categories() {
+		dict, err := words.CategoryDictionary(category)
+		if err != nil {
+			t.Fatalf("CategoryDictionary(%s): %v", category, err)
+		}
+		for length := words.MinLength; length <= 30; length++ {
+			for _, word := range dict.Words(length) {
+				game := solverGame(word, category)
+				for !IsSolved(game) {
+					s := NewSolver(game)
+					if !hasCandidate(s, word) {
+						t.Errorf("%s %q: not among the %d candidates for %q", category, word, s.Count(), compactPattern(game))
+						break
+					}
+					letter, err := s.BestLetter(MaxInformation)
+					if err != nil {
+						t.Errorf("%s %q: %v", category, word, err)
+						break
+					}
+					RegisterGuess(game, letter)
+				}
+			}
+		}
+	}
+}
+
+// Themed games draw candidates from the category list, not the language dictionary: a countries
+// game for "france" has every six-letter country as a candidate, and nothing else.
+func TestSolverCountries(t *testing.T) {
+	dict, _ := words.CategoryDictionary("countries")
+	s := NewSolver(solverGame("france", "countries"))
+	if !hasCandidate(s, "france") {
+		t.Fatalf("france not among the %d candidates", s.Count())
+	}
+	if s.Count() != len(dict.Words(6)) {
+		t.Errorf("Count = %d, want the %d six-letter countries", s.Count(), len(dict.Words(6)))
+	}
+}
+
+// Plain games use the language's dictionary, and a wrong letter rules out every word with it.
+func TestSolverPlainWords(t *testing.T) {
+	game := solverGame("apple", "")
+	before := NewSolver(game).Count()
+	if before == 0 || !hasCandidate(NewSolver(game), "apple") {
+		t.Fatalf("apple not among the %d candidates", before)
+	}
+	RegisterGuess(game, "z")
+	s := NewSolver(game)
+	if s.Count() >= before || !hasCandidate(s, "apple") {
+		t.Errorf("after a miss on z: %d candidates (was %d), apple present: %v", s.Count(), before, hasCandidate(s, "apple"))
+	}
+	for _, c := range s.Candidates {
+		for _, r := range c {
+			if r == 'z' {
+				t.Errorf("candidate %q contains the missed letter z", c)
+			}
+		}
+	}
+}
+
+// Words the solver's list doesn't have (custom words, file/API words) aren't counted at all.
+func TestCountCandidates(t *testing.T) {
+	if n := CountCandidates(solverGame("apple", "")); n == 0 {
+		t.Error("CountCandidates(apple) = 0, want the dictionary words that fit")
+	}
+	if n := CountCandidates(solverGame("qzxjv", "")); n != 0 {
+		t.Errorf("CountCandidates for a word not in the dictionary = %d, want 0", n)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	handlers "wordgame/api"
 	"wordgame/db"
+	"wordgame/session"
 
 	_ "modernc.org/sqlite"
 )
@@ -26,6 +27,21 @@ func main() {
 	// Initialize the DB and migrate schema, crash if it fails.
 	db.InitDB()
 
+	// Load the daily puzzle secret (generated and saved on first start if DAILY_SECRET is unset).
+	handlers.InitDailySecret()
+
+	// Reload games that were still being played when the server last stopped.
+	handlers.RestoreGames()
+
+	// Rate games that finished before ratings existed (first start after upgrading only).
+	handlers.BackfillRatings()
+
+	// Count games played and hints taken before those were counted (first start after upgrading only).
+	handlers.BackfillStats()
+
+	// Rename accounts registered under a name the game uses for the computer or a draw (first start after upgrading only).
+	handlers.RenameReservedUsers()
+
 	// Expose /static/ for frontend CSS/JS/assets.
 	fs := http.FileServer(http.Dir("./static"))
 	http.Handle("/static/", http.StripPrefix("/static/", fs))
@@ -74,8 +90,54 @@ func main() {
 	http.HandleFunc("/hint", handlers.HintHandler)               // Hint API: get a hint (AJAX)
 	http.HandleFunc("/ws", handlers.WebSocketHandler)            // WebSocket: multiplayer gameplay updates
 
+	// HOST MODE: Player 1 submits the secret word, Player 2 guesses alone
+	http.HandleFunc("/create_custom", handlers.CreateCustomHandler)
+
+	// GAME HISTORY: your finished games, paginated (?page=2, ?user=name, ?format=json)
+	http.HandleFunc("/history", handlers.HistoryHandler)
+
+	// PROFILES: a player's record and statistics (no login needed)
+	http.HandleFunc("GET /u/{username}", handlers.ProfileHandler)
+
+	// SPECTATORS: read-only live view of any game (no login needed)
+	http.HandleFunc("GET /watch/{id}", handlers.WatchHandler)  // Watch page; connects to /ws?watch=1
+	http.HandleFunc("GET /watch", handlers.WatchLookupHandler) // Home page form -> /watch/{id}
+
+	// LOBBY: browse open games and quick match (the list is pushed live over /ws/lobby)
+	http.HandleFunc("GET /lobby", handlers.LobbyHandler)             // Open games + Quick Match form
+	http.HandleFunc("POST /lobby/quick", handlers.QuickMatchHandler) // Join a compatible game or open one
+	http.HandleFunc("GET /ws/lobby", handlers.LobbyWebSocketHandler) // WebSocket: open-game list updates
+
+	// MATCHMAKING: ranked queue pairing players of similar rating (AI game offered on timeout)
+	http.HandleFunc("GET /matchmaking", handlers.MatchmakingHandler)             // Search page
+	http.HandleFunc("GET /ws/matchmaking", handlers.MatchmakingWebSocketHandler) // WebSocket: stay queued until matched
+
+	// JSON API v1: the game flow for bots and mobile clients (same rules as the WebSocket path)
+	http.HandleFunc("POST /api/v1/games", handlers.APICreateGameHandler)         // Create human-vs-human game
+	http.HandleFunc("POST /api/v1/games/ai", handlers.APICreateAIGameHandler)    // Create game vs. computer
+	http.HandleFunc("POST /api/v1/games/{id}/join", handlers.APIJoinGameHandler) // Take seat 2
+	http.HandleFunc("GET /api/v1/games/{id}", handlers.APIGameStateHandler)      // Current state
+	http.HandleFunc("POST /api/v1/games/{id}/guess", handlers.APIGuessHandler)   // Guess a letter
+	http.HandleFunc("POST /api/v1/games/{id}/hint", handlers.APIHintHandler)     // Use the hint
+	http.HandleFunc("GET /api/v1/leaderboard", handlers.APILeaderboardHandler)   // Top players
+	http.HandleFunc("GET /api/v1/lobby", handlers.APILobbyHandler)               // Open games
+	http.HandleFunc("POST /api/v1/lobby/quick", handlers.APIQuickMatchHandler)   // Quick match
+
+	// SETTINGS: personal API tokens (for bots) and the bot-account flag
+	http.HandleFunc("/settings", handlers.SettingsHandler)                  // Tokens + bot flag (GET)
+	http.HandleFunc("/settings/tokens", handlers.CreateTokenHandler)        // Create a token (POST)
+	http.HandleFunc("/settings/tokens/revoke", handlers.RevokeTokenHandler) // Revoke a token (POST)
+	http.HandleFunc("/settings/bot", handlers.BotFlagHandler)               // Set/clear the bot flag (POST)
+
+	// DAILY PUZZLE: same word for everyone each day, one attempt per user
+	http.HandleFunc("/daily", handlers.DailyHandler)                // Today's board (GET)
+	http.HandleFunc("/daily/guess", handlers.DailyGuessHandler)     // Submit a letter (POST)
+	http.HandleFunc("/daily/results", handlers.DailyResultsHandler) // Everyone's results for today
+
 	// Start background goroutine to relay messages from wsBroadcast (for live updates)
 	handlers.StartWSBroadcaster()
+	handlers.StartLobbyBroadcaster() // and pushes of the open-game list to the lobby
+	handlers.StartMatchmaker()       // and the ranked matchmaking queue
 
 	// -------- PORT DETECTION (Platform Adaptation) ---------
 	port := os.Getenv("PORT")
@@ -85,6 +147,7 @@ func main() {
 
 	// Startup message
 	fmt.Printf("Server running on port %s\n", port)
-	// Start HTTP server; fatal on error.
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	// Start HTTP server; fatal on error. Every request passes through the session
+	// middleware, which attaches the logged-in user (if any) to the request context.
+	log.Fatal(http.ListenAndServe(":"+port, session.Middleware(http.DefaultServeMux)))
 }
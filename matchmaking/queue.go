@@ -0,0 +1,186 @@
+// Package matchmaking pairs queued players by skill rating. A player is first matched only
+// with opponents of similar rating; the accepted rating gap widens the longer they wait, and
+// anyone still unmatched after the timeout is let go (the caller offers them an AI game).
+package matchmaking
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrReplaced is delivered to a ticket when the same player joins the queue again.
+var ErrReplaced = errors.New("queued again from somewhere else")
+
+// Config controls how fast the rating window widens and how long players wait.
+type Config struct {
+	InitialWindow int           // rating gap accepted straight away
+	WindowStep    int           // extra gap accepted after every StepEvery of waiting
+	StepEvery     time.Duration // how often the window widens
+	MaxWindow     int           // the window never grows past this
+	Timeout       time.Duration // give up on a player after this long
+	Tick          time.Duration // how often the queue looks for pairs
+}
+
+// DefaultConfig starts at +/-100 rating points, widens by 50 every 5 seconds up to +/-600,
+// and gives up after a minute.
+var DefaultConfig = Config{
+	InitialWindow: 100,
+	WindowStep:    50,
+	StepEvery:     5 * time.Second,
+	MaxWindow:     600,
+	Timeout:       time.Minute,
+	Tick:          time.Second,
+}
+
+// Ticket is one player waiting in the queue.
+type Ticket struct {
+	Player string
+	Rating int
+	Pool   string // only players in the same pool are paired (e.g. the word language)
+	Joined time.Time
+
+	result chan Result
+}
+
+// Result tells a queued player how their wait ended; exactly one is sent per ticket.
+type Result struct {
+	GameID   string // set when a match was made and its game created
+	Opponent string
+	TimedOut bool  // nobody suitable within Config.Timeout
+	Err      error // creating the game failed, or ErrReplaced
+}
+
+// MatchFunc creates the game for a new pair and returns its ID. It's called without the
+// queue's lock held; a is the player who has waited longer.
+type MatchFunc func(a, b Ticket) (string, error)
+
+// Queue holds waiting players and pairs them on every tick.
+type Queue struct {
+	cfg   Config
+	match MatchFunc
+
+	mu      sync.Mutex
+	tickets map[string]*Ticket // by player name: one ticket per player
+}
+
+// New returns an empty queue. Call Start to begin pairing.
+func New(cfg Config, match MatchFunc) *Queue {
+	return &Queue{cfg: cfg, match: match, tickets: make(map[string]*Ticket)}
+}
+
+// Start runs the pairing loop in the background for the life of the process.
+func (q *Queue) Start() {
+	go func() {
+		for now := range time.Tick(q.cfg.Tick) {
+			q.Pair(now)
+		}
+	}()
+}
+
+// Join queues player and returns the channel their Result arrives on.
+// Joining again replaces the earlier ticket (which gets ErrReplaced).
+func (q *Queue) Join(player string, rating int, pool string) <-chan Result {
+	t := &Ticket{Player: player, Rating: rating, Pool: pool, Joined: time.Now(), result: make(chan Result, 1)}
+
+	q.mu.Lock()
+	if old, ok := q.tickets[player]; ok {
+		old.result <- Result{Err: ErrReplaced}
+	}
+	q.tickets[player] = t
+	q.mu.Unlock()
+
+	q.Pair(time.Now()) // someone suitable may already be waiting
+	return t.result
+}
+
+// Leave takes player out of the queue (e.g. they closed the page); a no-op once they're matched.
+// Only the ticket behind result is removed, so a stale page can't cancel a newer search.
+func (q *Queue) Leave(player string, result <-chan Result) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if t, ok := q.tickets[player]; ok && (<-chan Result)(t.result) == result {
+		delete(q.tickets, player)
+	}
+}
+
+// Len is the number of players currently waiting.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tickets)
+}
+
+// Window is the rating gap a ticket accepts after waiting until now.
+func (q *Queue) Window(t Ticket, now time.Time) int {
+	window := q.cfg.InitialWindow
+	if q.cfg.StepEvery > 0 {
+		window += q.cfg.WindowStep * int(now.Sub(t.Joined)/q.cfg.StepEvery)
+	}
+	return min(window, q.cfg.MaxWindow)
+}
+
+// Pair matches up everyone it can as of now, then times out whoever has waited too long.
+// Longest-waiting players pick first, each taking the closest rating within both players' windows.
+func (q *Queue) Pair(now time.Time) {
+	var pairs [][2]*Ticket
+	var expired []*Ticket
+
+	q.mu.Lock()
+	waiting := make([]*Ticket, 0, len(q.tickets))
+	for _, t := range q.tickets {
+		waiting = append(waiting, t)
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].Joined.Before(waiting[j].Joined) })
+
+	taken := make(map[*Ticket]bool)
+	for i, a := range waiting {
+		if taken[a] {
+			continue
+		}
+		var best *Ticket
+		bestGap := 0
+		for _, b := range waiting[i+1:] {
+			if taken[b] || b.Pool != a.Pool {
+				continue
+			}
+			gap := abs(a.Rating - b.Rating)
+			if gap > q.Window(*a, now) || gap > q.Window(*b, now) {
+				continue
+			}
+			if best == nil || gap < bestGap {
+				best, bestGap = b, gap
+			}
+		}
+		if best != nil {
+			taken[a], taken[best] = true, true
+			pairs = append(pairs, [2]*Ticket{a, best})
+			delete(q.tickets, a.Player)
+			delete(q.tickets, best.Player)
+		} else if now.Sub(a.Joined) >= q.cfg.Timeout {
+			expired = append(expired, a)
+			delete(q.tickets, a.Player)
+		}
+	}
+	q.mu.Unlock()
+
+	// Deliver outside the lock: creating a game touches the game store and database
+	for _, t := range expired {
+		t.result <- Result{TimedOut: true}
+	}
+	for _, p := range pairs {
+		a, b := p[0], p[1]
+		id, err := q.match(*a, *b)
+		a.result <- Result{GameID: id, Opponent: b.Player, Err: err}
+		b.result <- Result{GameID: id, Opponent: a.Player, Err: err}
+	}
+}
+
+// Helper: Absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,221 @@
+package matchmaking
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testConfig = Config{
+	InitialWindow: 100,
+	WindowStep:    50,
+	StepEvery:     5 * time.Second,
+	MaxWindow:     300,
+	Timeout:       time.Minute,
+	Tick:          time.Second,
+}
+
+// A queued player for the tests: waited is how long before "now" they joined
+type testTicket struct {
+	player string
+	rating int
+	pool   string
+	waited time.Duration
+}
+
+// Helper: A queue holding the given tickets, and a func listing the pairs it has matched
+// ("a-b", the longer-waiting player first, sorted)
+func testQueue(now time.Time, tickets []testTicket) (*Queue, func() []string) {
+	var mu sync.Mutex
+	var matched []string
+	q := New(testConfig, func(a, b Ticket) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		matched = append(matched, a.Player+"-"+b.Player)
+		return a.Player + "-" + b.Player, nil
+	})
+	for _, t := range tickets {
+		pool := t.pool
+		if pool == "" {
+			pool = "en"
+		}
+		q.tickets[t.player] = &Ticket{
+			Player: t.player,
+			Rating: t.rating,
+			Pool:   pool,
+			Joined: now.Add(-t.waited),
+			result: make(chan Result, 1),
+		}
+	}
+	return q, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(matched)
+		return matched
+	}
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		waited time.Duration
+		want   int
+	}{
+		{"just joined", 0, 100},
+		{"not a full step yet", 4 * time.Second, 100},
+		{"one step", 5 * time.Second, 150},
+		{"two steps", 12 * time.Second, 200},
+		{"capped at MaxWindow", 10 * time.Minute, 300},
+	}
+	q := New(testConfig, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := q.Window(Ticket{Joined: now.Add(-tt.waited)}, now)
+			if got != tt.want {
+				t.Errorf("Window after %v = %d, want %d", tt.waited, got, tt.want)
+			}
+		})
+	}
+
+	// Without a step the window never widens
+	fixed := testConfig
+	fixed.StepEvery = 0
+	if got := New(fixed, nil).Window(Ticket{Joined: now.Add(-time.Hour)}, now); got != fixed.InitialWindow {
+		t.Errorf("Window with no StepEvery = %d, want %d", got, fixed.InitialWindow)
+	}
+}
+
+func TestPair(t *testing.T) {
+	tests := []struct {
+		name     string
+		tickets  []testTicket
+		want     []string // pairs made
+		timedOut []string // players let go
+	}{
+		{
+			name: "closest rating is picked",
+			tickets: []testTicket{
+				{"anna", 1500, "", 3 * time.Second},
+				{"bob", 1580, "", 2 * time.Second},
+				{"cara", 1520, "", time.Second},
+			},
+			want: []string{"anna-cara"},
+		},
+		{
+			name: "longest-waiting player picks first",
+			tickets: []testTicket{
+				{"anna", 1500, "", 3 * time.Second},
+				{"bob", 1545, "", 2 * time.Second},
+				{"cara", 1540, "", time.Second},
+			},
+			// bob and cara are closest to each other, but anna has waited longest
+			want: []string{"anna-cara"},
+		},
+		{
+			name: "everyone paired by closest gap",
+			tickets: []testTicket{
+				{"anna", 1500, "", 4 * time.Second},
+				{"bob", 1900, "", 3 * time.Second},
+				{"cara", 1560, "", 2 * time.Second},
+				{"dan", 1880, "", time.Second},
+			},
+			want: []string{"anna-cara", "bob-dan"},
+		},
+		{
+			name: "gap outside the window",
+			tickets: []testTicket{
+				{"anna", 1500, "", time.Second},
+				{"bob", 1650, "", time.Second},
+			},
+		},
+		{
+			name: "window widened by waiting",
+			tickets: []testTicket{
+				{"anna", 1500, "", 11 * time.Second},
+				{"bob", 1650, "", 10 * time.Second},
+			},
+			want: []string{"anna-bob"},
+		},
+		{
+			name: "the gap must fit both windows",
+			tickets: []testTicket{
+				{"anna", 1500, "", 30 * time.Second},
+				{"bob", 1650, "", time.Second},
+			},
+		},
+		{
+			name: "different pools never meet",
+			tickets: []testTicket{
+				{"anna", 1500, "en", 2 * time.Second},
+				{"bob", 1500, "pt", time.Second},
+			},
+		},
+		{
+			name: "timed out alone",
+			tickets: []testTicket{
+				{"anna", 1500, "", 2 * time.Minute},
+				{"bob", 2500, "", time.Second},
+			},
+			timedOut: []string{"anna"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			q, matched := testQueue(now, tt.tickets)
+			tickets := make(map[string]*Ticket)
+			for player, ticket := range q.tickets {
+				tickets[player] = ticket
+			}
+			q.Pair(now)
+
+			if got := matched(); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("pairs = %v, want %v", got, tt.want)
+			}
+			for _, pair := range tt.want {
+				a, b, _ := strings.Cut(pair, "-")
+				if res := <-tickets[a].result; res.Opponent != b || res.GameID != pair {
+					t.Errorf("%s got %+v, want game %s against %s", a, res, pair, b)
+				}
+				if res := <-tickets[b].result; res.Opponent != a || res.GameID != pair {
+					t.Errorf("%s got %+v, want game %s against %s", b, res, pair, a)
+				}
+			}
+			var timedOut []string
+			for _, player := range tt.timedOut {
+				if res := <-tickets[player].result; !res.TimedOut {
+					t.Errorf("%s got %+v, want a timeout", player, res)
+				}
+				timedOut = append(timedOut, player)
+			}
+			if want := len(tt.tickets) - 2*len(tt.want) - len(timedOut); q.Len() != want {
+				t.Errorf("%d players still waiting, want %d", q.Len(), want)
+			}
+		})
+	}
+}
+
+// Joining twice replaces the first ticket: a player is never paired with themselves,
+// however long they wait.
+func TestPairNeverSelf(t *testing.T) {
+	q, matched := testQueue(time.Now(), nil)
+	first := q.Join("anna", 1500, "en")
+	second := q.Join("anna", 1500, "en")
+	if res := <-first; res.Err != ErrReplaced {
+		t.Errorf("first ticket got %+v, want ErrReplaced", res)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("%d players waiting, want 1", q.Len())
+	}
+
+	q.Pair(time.Now().Add(testConfig.Timeout))
+	if got := matched(); len(got) != 0 {
+		t.Errorf("pairs = %v, want none", got)
+	}
+	if res := <-second; !res.TimedOut {
+		t.Errorf("second ticket got %+v, want a timeout", res)
+	}
+}
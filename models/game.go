@@ -1,8 +1,17 @@
 package models
 
+import "time"
+
 type Game struct {
 	ID                  string
 	Word                string
+	Category            string // word category key, e.g. "animals" ("" = any word)
+	Difficulty          string // "easy", "medium" or "hard" (rated from the word itself)
+	Language            string // language code, e.g. "en", "pt" ("" = English)
+	AccentInsensitive   bool   // guessing "a" also reveals "á", "ã", ...
+	CustomWord          bool   // Player1 picked the word and only watches; Player2 guesses alone
+	Daily               bool   // solo word-of-the-day puzzle: Player1 guesses alone, no winner on a loss
+	AIStrategy          string // games against the computer: how it guesses (logic.AIStrategies key, "" = default)
 	DisplayWord         string
 	GuessedLetters      map[string]bool
 	IncorrectGuesses    int
@@ -13,6 +22,9 @@ type Game struct {
 	Status              string // "waiting", "in_progress", "finished"
 	Winner              string
 	HasUsedHint         bool
+	HintUsedBy          string // player who revealed the hint ("" = not used)
 	HintText            string
 	GuessHistory        []string
+	Candidates          int       // dictionary words still fitting the board, counted after each guess (see logic.CountCandidates)
+	StartedAt           time.Time // when play began (both seats filled), for game duration
 }
@@ -0,0 +1,40 @@
+// Package rating implements the Elo rating system behind the leaderboard and matchmaking.
+// It's pure arithmetic; storing ratings is up to the caller.
+package rating
+
+import "math"
+
+// Initial is every player's rating before their first rated game.
+const Initial = 1200
+
+// ProvisionalGames is how many rated games a player's rating moves quickly for.
+const ProvisionalGames = 20
+
+// Score is a game result from one player's point of view.
+type Score float64
+
+const (
+	Loss Score = 0
+	Draw Score = 0.5
+	Win  Score = 1
+)
+
+// Expected is the score a player rated r is expected to take from an opponent rated opp (0..1).
+func Expected(r, opp int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opp-r)/400))
+}
+
+// K is how far one game can move a rating: new players' ratings settle fast, then steady.
+func K(gamesPlayed int) int {
+	if gamesPlayed < ProvisionalGames {
+		return 40
+	}
+	return 20
+}
+
+// Update returns a player's new rating after scoring score against an opponent rated opp,
+// given how many rated games the player had finished before this one.
+func Update(r, opp int, score Score, gamesPlayed int) int {
+	delta := float64(K(gamesPlayed)) * (float64(score) - Expected(r, opp))
+	return r + int(math.Round(delta))
+}
@@ -0,0 +1,144 @@
+// Package session handles login sessions: random tokens in an HttpOnly cookie, stored
+// server-side in SQLite, plus a middleware that puts the logged-in user in the request context.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+	"wordgame/db"
+)
+
+// CookieName is the cookie holding the session token.
+const CookieName = "session"
+
+// How long a login lasts before the user has to sign in again
+const sessionTTL = 7 * 24 * time.Hour
+
+// User is the authenticated user attached to a request.
+type User struct {
+	ID       int
+	Username string
+	IsBot    bool // bot account (see users.is_bot)
+	APIToken bool // authenticated with an API token rather than a login session
+}
+
+// Context key type (unexported so no other package can collide with it)
+type contextKey struct{}
+
+// -------- CREATE / DESTROY --------
+
+// Create starts a new session for the user and sets the session cookie.
+func Create(w http.ResponseWriter, r *http.Request, userID int) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	expires := time.Now().Add(sessionTTL)
+
+	// Opportunistic cleanup: drop everyone's expired sessions while we're writing anyway
+	if _, err := db.DB.Exec("DELETE FROM sessions WHERE expires_at < ?", time.Now().Unix()); err != nil {
+		log.Println("Session cleanup error:", err)
+	}
+	_, err = db.DB.Exec(
+		"INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)",
+		hashToken(token), userID, expires.Unix(),
+	)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,                 // JavaScript cannot read the token (helps against XSS)
+		SameSite: http.SameSiteLaxMode, // not sent on cross-site POSTs
+		Secure:   r.TLS != nil,
+	})
+	return nil
+}
+
+// Destroy deletes the request's session server-side (so a copied cookie stops working) and clears the cookie.
+func Destroy(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(CookieName); err == nil && c.Value != "" {
+		if _, err := db.DB.Exec("DELETE FROM sessions WHERE token_hash = ?", hashToken(c.Value)); err != nil {
+			log.Println("Session delete error:", err)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:   CookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+}
+
+// -------- LOOKUP / MIDDLEWARE --------
+
+// Middleware resolves the API token ("Authorization: Bearer ...") or, failing that, the session
+// cookie on every request and, if it's valid, stores the user in the request context (read it
+// back with FromContext). It never rejects a request; handlers decide whether login is required.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := lookupToken(r)
+		if !ok && r.Header.Get("Authorization") == "" {
+			user, ok = lookup(r)
+		}
+		if ok {
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, user))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// FromContext returns the logged-in user for a request that passed through Middleware.
+func FromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(contextKey{}).(User)
+	return user, ok
+}
+
+// Helper: Find the unexpired session matching the request's cookie
+func lookup(r *http.Request) (User, bool) {
+	c, err := r.Cookie(CookieName)
+	if err != nil || c.Value == "" {
+		return User{}, false
+	}
+	var user User
+	err = db.DB.QueryRow(`
+        SELECT u.id, u.username, u.is_bot
+        FROM sessions s
+        JOIN users u ON s.user_id = u.id
+        WHERE s.token_hash = ? AND s.expires_at > ?
+    `, hashToken(c.Value), time.Now().Unix()).Scan(&user.ID, &user.Username, &user.IsBot)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("Session lookup error:", err)
+		}
+		return User{}, false
+	}
+	return user, true
+}
+
+// -------- TOKENS --------
+
+// Helper: 32 random bytes, hex-encoded
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Helper: Tokens are stored hashed, so a leaked database can't be used to hijack sessions
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
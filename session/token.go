@@ -0,0 +1,134 @@
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"wordgame/db"
+)
+
+// TokenPrefix marks API tokens so they're easy to spot (and to scan for if one leaks).
+const TokenPrefix = "wg_"
+
+// Most tokens a single user can hold at once
+const maxTokensPerUser = 10
+
+// ErrTooManyTokens is returned by CreateToken when the user already has maxTokensPerUser tokens.
+var ErrTooManyTokens = errors.New("too many API tokens; revoke one first")
+
+// Token describes a personal API token (the secret itself is only shown once, at creation).
+type Token struct {
+	ID         int
+	Name       string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+}
+
+// Token uses closer together than this only record the first one in last_used_at
+const lastUsedInterval = time.Minute
+
+// CreateToken issues a new API token for the user and returns the secret.
+// Only its SHA-256 is stored, so the caller must show it to the user right away.
+// With bot set, the account is flagged as a bot too (for good, like BotFlagHandler).
+func CreateToken(userID int, name string, bot bool) (string, error) {
+	var count int
+	if err := db.DB.QueryRow("SELECT COUNT(*) FROM api_tokens WHERE user_id = ?", userID).Scan(&count); err != nil {
+		return "", err
+	}
+	if count >= maxTokensPerUser {
+		return "", ErrTooManyTokens
+	}
+
+	secret, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	secret = TokenPrefix + secret
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(
+		"INSERT INTO api_tokens (user_id, name, token_hash, created_at) VALUES (?, ?, ?, ?)",
+		userID, name, hashToken(secret), time.Now().UTC(),
+	)
+	if err != nil {
+		return "", err
+	}
+	if bot {
+		if _, err := tx.Exec("UPDATE users SET is_bot = 1 WHERE id = ?", userID); err != nil {
+			return "", err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// ListTokens returns the user's API tokens, newest first.
+func ListTokens(userID int) ([]Token, error) {
+	rows, err := db.DB.Query(`
+        SELECT id, name, created_at, last_used_at
+        FROM api_tokens
+        WHERE user_id = ?
+        ORDER BY id DESC
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []Token
+	for rows.Next() {
+		var t Token
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt, &t.LastUsedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokeToken deletes one of the user's tokens (other users' token IDs are ignored).
+func RevokeToken(userID, tokenID int) error {
+	_, err := db.DB.Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", tokenID, userID)
+	return err
+}
+
+// Helper: Resolve an "Authorization: Bearer <token>" header to its user
+func lookupToken(r *http.Request) (User, bool) {
+	secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(secret, TokenPrefix) {
+		return User{}, false
+	}
+	var user User
+	var tokenID int
+	var lastUsed sql.NullTime
+	err := db.DB.QueryRow(`
+        SELECT t.id, t.last_used_at, u.id, u.username, u.is_bot
+        FROM api_tokens t
+        JOIN users u ON t.user_id = u.id
+        WHERE t.token_hash = ?
+    `, hashToken(strings.TrimSpace(secret))).Scan(&tokenID, &lastUsed, &user.ID, &user.Username, &user.IsBot)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("API token lookup error:", err)
+		}
+		return User{}, false
+	}
+	user.APIToken = true
+	// A bot polling the API would otherwise write to the database on every request
+	now := time.Now().UTC()
+	if !lastUsed.Valid || now.Sub(lastUsed.Time) > lastUsedInterval {
+		if _, err := db.DB.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", now, tokenID); err != nil {
+			log.Println("API token update error:", err)
+		}
+	}
+	return user, true
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"sync"
+	"time"
+	"wordgame/models"
+)
+
+// One live game plus the lock guarding it
+type entry struct {
+	mu   sync.Mutex
+	game *models.Game
+
+	// Copied from the game after every change, guarded by MemoryStore.mu (not entry.mu),
+	// so the lobby and eviction can check them without waiting on the game's lock
+	waiting    bool      // Status is "waiting"
+	finishedAt time.Time // when Status became "finished" (zero = still live)
+}
+
+// MemoryStore keeps games in a map only; everything is lost on restart.
+// Finished games are evicted FinishedGameTTL after they end.
+type MemoryStore struct {
+	mu    sync.Mutex // guards the map itself (and each entry's status copy), not the games in it
+	games map[string]*entry
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{games: make(map[string]*entry)}
+}
+
+// Create adds a new game; ErrExists if its ID is taken.
+func (s *MemoryStore) Create(game *models.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Opportunistic cleanup: drop games whose result screen is long gone while we're here anyway
+	s.evictFinished(time.Now())
+	if _, ok := s.games[game.ID]; ok {
+		return ErrExists
+	}
+	e := &entry{game: game}
+	s.noteStatus(e)
+	s.games[game.ID] = e
+	return nil
+}
+
+// View runs fn with the game locked, for reading only.
+func (s *MemoryStore) View(id string, fn func(game *models.Game) error) error {
+	return s.Update(id, fn)
+}
+
+// Update runs fn with the game locked.
+func (s *MemoryStore) Update(id string, fn func(game *models.Game) error) error {
+	e := s.lookup(id)
+	if e == nil {
+		return ErrNotFound
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	err := fn(e.game)
+	// Lock order is always game, then map (never the other way round)
+	s.mu.Lock()
+	s.noteStatus(e)
+	s.mu.Unlock()
+	return err
+}
+
+// EachWaiting runs fn on every waiting game, each locked in turn.
+func (s *MemoryStore) EachWaiting(fn func(game *models.Game)) {
+	// Pick the entries first so the map lock isn't held while waiting on game locks
+	s.mu.Lock()
+	var waiting []*entry
+	for _, e := range s.games {
+		if e.waiting {
+			waiting = append(waiting, e)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, e := range waiting {
+		e.mu.Lock()
+		fn(e.game)
+		e.mu.Unlock()
+	}
+}
+
+// Helper: Find a game's entry (nil if missing); only the map lock is taken
+func (s *MemoryStore) lookup(id string) *entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.games[id]
+}
+
+// Helper: Add or replace a game without checking for an existing one (used when restoring)
+func (s *MemoryStore) put(game *models.Game) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := &entry{game: game}
+	s.noteStatus(e)
+	s.games[game.ID] = e
+}
+
+// Helper: Copy the game's status into its entry. Caller holds the game's lock (or owns the
+// game) and the map lock.
+func (s *MemoryStore) noteStatus(e *entry) {
+	e.waiting = e.game.Status == "waiting"
+	if e.game.Status == "finished" && e.finishedAt.IsZero() {
+		e.finishedAt = time.Now()
+	}
+}
+
+// Helper: Drop games that finished more than FinishedGameTTL ago. Caller holds the map lock.
+func (s *MemoryStore) evictFinished(now time.Time) {
+	for id, e := range s.games {
+		if !e.finishedAt.IsZero() && now.Sub(e.finishedAt) > FinishedGameTTL {
+			delete(s.games, id)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"wordgame/models"
+)
+
+// Helper: A fresh game with the given ID
+func testGame(id string) *models.Game {
+	return &models.Game{ID: id, Word: "apple", Status: "in_progress", GuessedLetters: make(map[string]bool)}
+}
+
+func TestMemoryStoreCreate(t *testing.T) {
+	s := NewMemoryStore()
+	if err := s.Create(testGame("abcd")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := s.Create(testGame("abcd")); err != ErrExists {
+		t.Fatalf("Create with a taken ID = %v, want ErrExists", err)
+	}
+	err := s.View("abcd", func(game *models.Game) error {
+		if game.Word != "apple" {
+			t.Errorf("View got word %q, want %q", game.Word, "apple")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+}
+
+func TestMemoryStoreNotFound(t *testing.T) {
+	s := NewMemoryStore()
+	called := false
+	fn := func(game *models.Game) error { called = true; return nil }
+	if err := s.View("nope", fn); err != ErrNotFound {
+		t.Errorf("View of a missing game = %v, want ErrNotFound", err)
+	}
+	if err := s.Update("nope", fn); err != ErrNotFound {
+		t.Errorf("Update of a missing game = %v, want ErrNotFound", err)
+	}
+	if called {
+		t.Error("callback ran for a missing game")
+	}
+}
+
+func TestMemoryStoreUpdatePassesError(t *testing.T) {
+	s := NewMemoryStore()
+	s.Create(testGame("abcd"))
+	errBoom := errors.New("boom")
+	if err := s.Update("abcd", func(game *models.Game) error { return errBoom }); err != errBoom {
+		t.Errorf("Update = %v, want the callback's error", err)
+	}
+}
+
+// Many goroutines updating one game: with the per-game lock every increment lands (run with -race).
+func TestMemoryStoreConcurrentUpdates(t *testing.T) {
+	s := NewMemoryStore()
+	s.Create(testGame("abcd"))
+
+	const workers, perWorker = 20, 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				s.Update("abcd", func(game *models.Game) error {
+					game.IncorrectGuesses++
+					game.GuessHistory = append(game.GuessHistory, "x")
+					return nil
+				})
+				s.View("abcd", func(game *models.Game) error {
+					if len(game.GuessHistory) != game.IncorrectGuesses {
+						t.Errorf("saw a half-done update: %d guesses, %d misses", len(game.GuessHistory), game.IncorrectGuesses)
+					}
+					return nil
+				})
+			}
+		}()
+	}
+	// Readers walking every game at the same time
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				s.EachWaiting(func(game *models.Game) { _ = len(game.GuessHistory) })
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.View("abcd", func(game *models.Game) error {
+		if game.IncorrectGuesses != workers*perWorker {
+			t.Errorf("IncorrectGuesses = %d, want %d", game.IncorrectGuesses, workers*perWorker)
+		}
+		return nil
+	})
+}
+
+// Creating games while others are read and updated touches the map concurrently (run with -race).
+func TestMemoryStoreConcurrentCreate(t *testing.T) {
+	s := NewMemoryStore()
+	ids := []string{"aaaa", "bbbb", "cccc", "dddd", "eeee", "ffff", "gggg", "hhhh"}
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			game := testGame(id)
+			game.Status = "waiting"
+			if err := s.Create(game); err != nil {
+				t.Errorf("Create(%s): %v", id, err)
+			}
+			if i%2 == 0 {
+				s.Update(id, func(game *models.Game) error { game.Status = "in_progress"; return nil })
+			}
+			s.EachWaiting(func(game *models.Game) {})
+		}(i, id)
+	}
+	wg.Wait()
+
+	seen := 0
+	s.EachWaiting(func(game *models.Game) {
+		if game.Status != "waiting" {
+			t.Errorf("EachWaiting visited %s, which is %s", game.ID, game.Status)
+		}
+		seen++
+	})
+	if seen != len(ids)/2 {
+		t.Errorf("EachWaiting visited %d games, want %d", seen, len(ids)/2)
+	}
+}
+
+// A game held locked by a slow Update doesn't stall EachWaiting when it isn't waiting.
+func TestMemoryStoreEachWaitingSkipsBusyGames(t *testing.T) {
+	s := NewMemoryStore()
+	s.Create(testGame("busy"))
+	open := testGame("open")
+	open.Status = "waiting"
+	s.Create(open)
+
+	locked, release := make(chan struct{}), make(chan struct{})
+	go s.Update("busy", func(game *models.Game) error {
+		close(locked)
+		<-release
+		return nil
+	})
+	<-locked
+	defer close(release)
+
+	done := make(chan []string)
+	go func() {
+		var ids []string
+		s.EachWaiting(func(game *models.Game) { ids = append(ids, game.ID) })
+		done <- ids
+	}()
+	select {
+	case ids := <-done:
+		if len(ids) != 1 || ids[0] != "open" {
+			t.Errorf("EachWaiting visited %v, want [open]", ids)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("EachWaiting blocked on a game that isn't waiting")
+	}
+}
+
+// Finished games are dropped once their result screen has had FinishedGameTTL.
+func TestMemoryStoreEvictsFinishedGames(t *testing.T) {
+	s := NewMemoryStore()
+	s.Create(testGame("done"))
+	s.Create(testGame("live"))
+	s.Update("done", func(game *models.Game) error { game.Status = "finished"; return nil })
+
+	// Still there for the result screen
+	s.Create(testGame("new1"))
+	if err := s.View("done", func(game *models.Game) error { return nil }); err != nil {
+		t.Fatalf("finished game evicted straight away: %v", err)
+	}
+
+	// Pretend it finished long ago; the next Create clears it out
+	s.mu.Lock()
+	s.games["done"].finishedAt = time.Now().Add(-FinishedGameTTL - time.Minute)
+	s.mu.Unlock()
+	s.Create(testGame("new2"))
+	if err := s.View("done", func(game *models.Game) error { return nil }); err != ErrNotFound {
+		t.Errorf("View of an expired finished game = %v, want ErrNotFound", err)
+	}
+	if err := s.View("live", func(game *models.Game) error { return nil }); err != nil {
+		t.Errorf("a game in progress was evicted: %v", err)
+	}
+}
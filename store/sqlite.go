@@ -0,0 +1,111 @@
+package store
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+	"wordgame/db"
+	"wordgame/models"
+)
+
+// Snapshots untouched for this long are treated as abandoned and not restored
+const liveGameMaxAge = 24 * time.Hour
+
+// SQLiteStore keeps games in memory like MemoryStore and writes every change through to the
+// live_games table (one JSON snapshot per unfinished game), so games survive a server restart.
+// Finished games stay in memory for the result screen (until evicted, see FinishedGameTTL)
+// but their snapshot is dropped.
+type SQLiteStore struct {
+	*MemoryStore
+}
+
+// NewSQLiteStore returns an empty store backed by db.DB; call Restore once the DB is initialized.
+func NewSQLiteStore() *SQLiteStore {
+	return &SQLiteStore{MemoryStore: NewMemoryStore()}
+}
+
+// Create adds a new game and snapshots it.
+func (s *SQLiteStore) Create(game *models.Game) error {
+	if err := s.MemoryStore.Create(game); err != nil {
+		return err
+	}
+	// Nobody else can reach the game before Create returns, but take its lock for consistency
+	return s.MemoryStore.Update(game.ID, func(game *models.Game) error {
+		s.snapshot(game)
+		return nil
+	})
+}
+
+// Update runs fn with the game locked and snapshots the result (unless fn failed).
+func (s *SQLiteStore) Update(id string, fn func(game *models.Game) error) error {
+	return s.MemoryStore.Update(id, func(game *models.Game) error {
+		if err := fn(game); err != nil {
+			return err
+		}
+		s.snapshot(game)
+		return nil
+	})
+}
+
+// Restore reloads the unfinished games saved before the last shutdown.
+// Must run after db.InitDB and before the server starts accepting requests.
+func (s *SQLiteStore) Restore() {
+	// Forget games nobody has touched for a day (e.g. a waiting room that was never joined)
+	_, err := db.DB.Exec("DELETE FROM live_games WHERE updated_at < ?", time.Now().UTC().Add(-liveGameMaxAge))
+	if err != nil {
+		log.Println("Game restore error:", err)
+	}
+
+	rows, err := db.DB.Query("SELECT code, state FROM live_games")
+	if err != nil {
+		log.Println("Game restore error:", err)
+		return
+	}
+	defer rows.Close()
+
+	restored := 0
+	for rows.Next() {
+		var code, state string
+		if err := rows.Scan(&code, &state); err != nil {
+			log.Println("Game restore error:", err)
+			continue
+		}
+		game := &models.Game{}
+		if err := json.Unmarshal([]byte(state), game); err != nil {
+			log.Printf("Game restore error: skipping %s: %v", code, err)
+			continue
+		}
+		if game.GuessedLetters == nil {
+			game.GuessedLetters = make(map[string]bool)
+		}
+		s.put(game)
+		restored++
+	}
+	if restored > 0 {
+		log.Printf("Restored %d in-progress game(s)", restored)
+	}
+}
+
+// Helper: Save the game's current state, or drop its snapshot once it is finished
+// (finished games are recorded in the games table instead). Caller holds the game's lock.
+func (s *SQLiteStore) snapshot(game *models.Game) {
+	if game.Status == "finished" {
+		if _, err := db.DB.Exec("DELETE FROM live_games WHERE code = ?", game.ID); err != nil {
+			log.Println("Game snapshot delete error:", err)
+		}
+		return
+	}
+	state, err := json.Marshal(game)
+	if err != nil {
+		log.Println("Game snapshot error:", err)
+		return
+	}
+	_, err = db.DB.Exec(`
+        INSERT INTO live_games (code, state, updated_at)
+        VALUES (?, ?, ?)
+        ON CONFLICT(code) DO UPDATE SET state = excluded.state, updated_at = excluded.updated_at
+    `, game.ID, string(state), time.Now().UTC())
+	if err != nil {
+		log.Println("Game snapshot error:", err)
+	}
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"wordgame/db"
+	"wordgame/models"
+)
+
+// The SQLite tests share one throwaway database (db.InitDB only runs once per process)
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "store-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("DB_PATH", filepath.Join(dir, "test.db"))
+	db.InitDB()
+	code := m.Run()
+	db.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// Helper: The snapshot saved for a game (nil if there's none)
+func loadSnapshot(t *testing.T, id string) *models.Game {
+	t.Helper()
+	var state string
+	err := db.DB.QueryRow("SELECT state FROM live_games WHERE code = ?", id).Scan(&state)
+	if err != nil {
+		return nil
+	}
+	game := &models.Game{}
+	if err := json.Unmarshal([]byte(state), game); err != nil {
+		t.Fatalf("bad snapshot for %s: %v", id, err)
+	}
+	return game
+}
+
+func TestSQLiteStoreSnapshots(t *testing.T) {
+	s := NewSQLiteStore()
+	if err := s.Create(testGame("snap")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if loadSnapshot(t, "snap") == nil {
+		t.Fatal("Create didn't snapshot the game")
+	}
+
+	s.Update("snap", func(game *models.Game) error {
+		game.GuessHistory = append(game.GuessHistory, "e")
+		return nil
+	})
+	if got := loadSnapshot(t, "snap"); got == nil || len(got.GuessHistory) != 1 {
+		t.Fatalf("snapshot after Update = %+v, want one guess", got)
+	}
+
+	s.Update("snap", func(game *models.Game) error {
+		game.Status = "finished"
+		return nil
+	})
+	if loadSnapshot(t, "snap") != nil {
+		t.Error("a finished game's snapshot wasn't dropped")
+	}
+	// ...but it stays in memory for the result screen
+	if err := s.View("snap", func(game *models.Game) error { return nil }); err != nil {
+		t.Errorf("finished game gone from memory: %v", err)
+	}
+}
+
+func TestSQLiteStoreRestore(t *testing.T) {
+	s := NewSQLiteStore()
+	s.Create(testGame("rest"))
+	s.Update("rest", func(game *models.Game) error {
+		game.GuessedLetters["a"] = true
+		game.GuessHistory = []string{"a"}
+		return nil
+	})
+
+	// A "restarted server": a new store reading the same database
+	restored := NewSQLiteStore()
+	restored.Restore()
+	err := restored.View("rest", func(game *models.Game) error {
+		if !game.GuessedLetters["a"] || len(game.GuessHistory) != 1 {
+			t.Errorf("restored game = %+v, want the guess of 'a'", game)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("game not restored: %v", err)
+	}
+}
+
+// Concurrent updates all reach memory and the last snapshot (run with -race).
+func TestSQLiteStoreConcurrentUpdates(t *testing.T) {
+	s := NewSQLiteStore()
+	s.Create(testGame("conc"))
+
+	const workers, perWorker = 8, 10
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				s.Update("conc", func(game *models.Game) error {
+					game.IncorrectGuesses++
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := loadSnapshot(t, "conc"); got == nil || got.IncorrectGuesses != workers*perWorker {
+		t.Errorf("snapshot = %+v, want %d misses", got, workers*perWorker)
+	}
+}
@@ -0,0 +1,38 @@
+// Package store keeps the live (in-memory) games shared by the HTTP handlers and WebSocket goroutines.
+package store
+
+import (
+	"errors"
+	"time"
+	"wordgame/models"
+)
+
+var (
+	// ErrNotFound is returned when no live game has the given ID.
+	ErrNotFound = errors.New("game not found")
+	// ErrExists is returned by Create when the game ID is already taken.
+	ErrExists = errors.New("game ID already in use")
+)
+
+// GameStore holds every live game by its 4-letter ID.
+// Games are only ever touched inside View/Update, which hold that game's lock for the whole
+// callback, so e.g. register guess -> AI reply -> broadcast happens atomically per game.
+// Callbacks must not call back into the store for the same game (the lock is not reentrant).
+type GameStore interface {
+	// Create adds a new game; ErrExists if its ID is taken.
+	Create(game *models.Game) error
+	// View runs fn with the game locked, for reading only.
+	View(id string, fn func(game *models.Game) error) error
+	// Update runs fn with the game locked and keeps the changes (a non-nil error from fn means
+	// "nothing changed" and is passed back to the caller).
+	Update(id string, fn func(game *models.Game) error) error
+	// EachWaiting runs fn on every game whose Status is "waiting", one at a time with that game's
+	// lock held (in no particular order). Other games are skipped without touching their lock, so
+	// a slow Update elsewhere doesn't hold it up. Games whose status changes
+	// meanwhile may or may not be visited; check the status again inside fn.
+	EachWaiting(fn func(game *models.Game))
+}
+
+// FinishedGameTTL is how long a finished game stays in the store for its result screen
+// before it is evicted (it's in the games table by then).
+const FinishedGameTTL = 15 * time.Minute
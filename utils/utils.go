@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"net/http"
 	"strings"
+	"wordgame/session"
 )
 
 // ----------- ID GENERATOR -----------
@@ -52,7 +53,7 @@ func GetWord(length int) string {
 // ----------- TEMPLATE RENDERING: FULL PAGE (with base layout) -----------
 
 // RenderPage renders a full page using base.html + a specific page template.
-// - If "User" isn't in data, it sets it from the user cookie (if present).
+// - If "User" isn't in data, it sets it from the session (if logged in).
 // - Renders with "base" as the root template.
 // Example: RenderPage(w, r, "gameplay.html", data)
 func RenderPage(w http.ResponseWriter, r *http.Request, file string, data map[string]interface{}) {
@@ -61,8 +62,8 @@ func RenderPage(w http.ResponseWriter, r *http.Request, file string, data map[st
 
 	// Populate "User" for navigation, if not already in data.
 	if _, ok := data["User"]; !ok {
-		if c, err := r.Cookie("user"); err == nil {
-			data["User"] = c.Value
+		if u, ok := session.FromContext(r.Context()); ok {
+			data["User"] = u.Username
 		}
 	}
 
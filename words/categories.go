@@ -0,0 +1,66 @@
+package words
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// -------- THEMED WORD CATEGORIES --------
+
+// Each file in lists/categories is one category; the file name (minus .txt) is the category key.
+var (
+	categories     map[string]*Dictionary
+	categoriesOnce sync.Once
+)
+
+// loadCategories parses every embedded category list once.
+func loadCategories() {
+	categoriesOnce.Do(func() {
+		categories = make(map[string]*Dictionary)
+		files, err := fs.Glob(lists, "lists/categories/*.txt")
+		if err != nil {
+			log.Println("Category lists unavailable:", err)
+			return
+		}
+		for _, file := range files {
+			f, err := lists.Open(file)
+			if err != nil {
+				log.Printf("Skipping category %s: %v", file, err)
+				continue
+			}
+			dict, err := LoadDictionary(f)
+			f.Close()
+			if err != nil {
+				log.Printf("Skipping category %s: %v", file, err)
+				continue
+			}
+			categories[strings.TrimSuffix(path.Base(file), ".txt")] = dict
+		}
+	})
+}
+
+// Categories returns the keys of all bundled categories, sorted alphabetically (e.g. "animals", "food").
+func Categories() []string {
+	loadCategories()
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CategoryDictionary returns the word list for a category key, or an error if it doesn't exist.
+func CategoryDictionary(name string) (*Dictionary, error) {
+	loadCategories()
+	dict, ok := categories[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown category %q", name)
+	}
+	return dict, nil
+}
@@ -0,0 +1,81 @@
+package words
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// -------- HOST-CHOSEN WORDS --------
+
+var (
+	banned     *Dictionary
+	bannedOnce sync.Once
+)
+
+// IsBanned reports whether word (or any word of a phrase) is on the embedded banned-word list.
+func IsBanned(word string) bool {
+	bannedOnce.Do(func() {
+		f, err := lists.Open("lists/banned.txt")
+		if err != nil {
+			panic("words: embedded banned list missing: " + err.Error())
+		}
+		defer f.Close()
+
+		banned, err = LoadDictionary(f)
+		if err != nil {
+			panic("words: embedded banned list invalid: " + err.Error())
+		}
+	})
+
+	parts := strings.FieldsFunc(strings.ToLower(word), func(c rune) bool {
+		return !unicode.IsLetter(c)
+	})
+	for _, part := range parts {
+		if banned.Contains(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCustomWord checks a secret word typed in by a game host and returns it normalized
+// (lowercase, single spaces). The word must use the language's alphabet (phrases may also contain
+// spaces, hyphens and apostrophes), have MinLength-MaxLength letters, appear in the language's
+// dictionary (or an English category list), and not be banned.
+func ValidateCustomWord(word string, lang *Language) (string, error) {
+	word = strings.Join(strings.Fields(strings.ToLower(word)), " ")
+
+	for _, c := range word {
+		if !lang.HasLetter(c) && !strings.ContainsRune(" -'", c) {
+			return "", fmt.Errorf("%q is not allowed in a %s word", c, lang.Name)
+		}
+	}
+	if n := LetterCount(word); n < MinLength || n > MaxLength {
+		return "", fmt.Errorf("word must have %d-%d letters", MinLength, MaxLength)
+	}
+	if IsBanned(word) {
+		return "", fmt.Errorf("that word is not allowed")
+	}
+	if !inAnyDictionary(word, lang) {
+		return "", fmt.Errorf("%q is not in the dictionary", word)
+	}
+	return word, nil
+}
+
+// inAnyDictionary reports whether word is in the language's word list or, for English, any category list.
+func inAnyDictionary(word string, lang *Language) bool {
+	if lang.Dictionary().Contains(word) {
+		return true
+	}
+	if lang.Code != DefaultLanguage {
+		return false
+	}
+	for _, name := range Categories() {
+		if dict, err := CategoryDictionary(name); err == nil && dict.Contains(word) {
+			return true
+		}
+	}
+	return false
+}
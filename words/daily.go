@@ -0,0 +1,38 @@
+package words
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"sync"
+)
+
+// Word lengths used for the daily puzzle (long enough to be interesting, short enough for one attempt).
+const (
+	dailyMinLength = 5
+	dailyMaxLength = 8
+)
+
+var (
+	dailyWords     []string
+	dailyWordsOnce sync.Once
+)
+
+// DailyWord deterministically picks the word of the day for date ("2006-01-02") from the English
+// dictionary. The choice is an HMAC of the date keyed with secret, so everyone gets the same word
+// but nobody can work out future words without the server secret.
+func DailyWord(date, secret string) string {
+	dailyWordsOnce.Do(func() {
+		dict := Default()
+		for l := dailyMinLength; l <= dailyMaxLength; l++ {
+			dailyWords = append(dailyWords, dict.Words(l)...)
+		}
+		sort.Strings(dailyWords) // stable order regardless of file layout
+	})
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(date))
+	n := binary.BigEndian.Uint64(mac.Sum(nil)[:8])
+	return dailyWords[n%uint64(len(dailyWords))]
+}
@@ -0,0 +1,104 @@
+package words
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Word length limits supported by the bundled dictionary (matches the 3-10 range on the forms).
+const (
+	MinLength = 3
+	MaxLength = 10
+)
+
+// Word lists compiled into the binary so games work without any network access.
+//
+//go:embed lists/*.txt lists/categories/*.txt
+var lists embed.FS
+
+// -------- DICTIONARY --------
+
+// Dictionary is an in-memory word list indexed by word length.
+// Entries may be phrases ("ice cream"); their length is the number of letters (see LetterCount).
+type Dictionary struct {
+	byLength map[int][]string // letter count -> words of that length
+	all      map[string]bool  // fast membership lookup
+
+	frequency map[rune]float64 // letter frequencies used for difficulty (nil = English)
+
+	// Lazily computed difficulty stats (see difficulty.go)
+	patterns          map[string]int // letter pattern -> number of words sharing it
+	patternsOnce      sync.Once
+	tierLow, tierHigh float64 // score cut-offs between easy/medium and medium/hard
+	tiersOnce         sync.Once
+}
+
+// LoadDictionary reads one word per line from r.
+// Blank lines and lines starting with "#" are skipped; words are lowercased and de-duplicated.
+func LoadDictionary(r io.Reader) (*Dictionary, error) {
+	d := &Dictionary{
+		byLength: make(map[int][]string),
+		all:      make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") || d.all[word] {
+			continue
+		}
+		d.all[word] = true
+		d.byLength[LetterCount(word)] = append(d.byLength[LetterCount(word)], word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(d.all) == 0 {
+		return nil, fmt.Errorf("dictionary is empty")
+	}
+	return d, nil
+}
+
+// LetterCount returns how many letters a word or phrase has, ignoring spaces and punctuation.
+func LetterCount(word string) int {
+	n := 0
+	for _, c := range word {
+		if unicode.IsLetter(c) {
+			n++
+		}
+	}
+	return n
+}
+
+// Words returns every word of the given length (nil if there are none).
+func (d *Dictionary) Words(length int) []string {
+	return d.byLength[length]
+}
+
+// Contains reports whether word is in the dictionary (case-insensitive).
+func (d *Dictionary) Contains(word string) bool {
+	return d.all[strings.ToLower(word)]
+}
+
+// Random picks a random word of the given length, or returns an error if none exist.
+func (d *Dictionary) Random(length int) (string, error) {
+	candidates := d.byLength[length]
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no words of length %d", length)
+	}
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
+// -------- BUNDLED ENGLISH DICTIONARY --------
+
+// Default returns the embedded English dictionary, parsed once on first use.
+func Default() *Dictionary {
+	en, _ := LanguageByCode(DefaultLanguage)
+	return en.Dictionary()
+}
@@ -0,0 +1,177 @@
+package words
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Difficulty is a coarse word difficulty tier ("" means no preference).
+type Difficulty string
+
+const (
+	DifficultyAny    Difficulty = ""
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+)
+
+// ParseDifficulty converts form input ("easy", "Medium", "") into a Difficulty.
+func ParseDifficulty(s string) (Difficulty, error) {
+	switch d := Difficulty(strings.ToLower(strings.TrimSpace(s))); d {
+	case DifficultyAny, DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return d, nil
+	default:
+		return DifficultyAny, fmt.Errorf("unknown difficulty %q", s)
+	}
+}
+
+// -------- SCORING --------
+
+// Weights for each component of the difficulty score (sum to 1).
+const (
+	rarityWeight    = 0.40 // rare letters are guessed late
+	repeatWeight    = 0.20 // few distinct letters = few chances to hit
+	vowelWeight     = 0.15 // vowels are the usual opening guesses
+	ambiguityWeight = 0.25 // many look-alike words make the pattern hard to pin down
+)
+
+// Score rates how hard word is to guess, from 0 (trivial) to 100 (brutal).
+// Combines letter rarity, repeated letters, vowel scarcity, and how many words
+// in this dictionary share the same letter pattern.
+func (d *Dictionary) Score(word string) float64 {
+	word = strings.ToLower(word)
+	if LetterCount(word) == 0 {
+		return 0
+	}
+
+	// Letter rarity: average over distinct letters, relative to the most common letter.
+	unique := make(map[rune]bool)
+	vowels := 0
+	length := 0
+	for _, c := range word {
+		if !unicode.IsLetter(c) {
+			continue // spaces and punctuation in phrases are given away for free
+		}
+		length++
+		unique[c] = true
+		if strings.ContainsRune("aeiou", FoldAccent(c)) {
+			vowels++
+		}
+	}
+	freq := d.letterFrequency()
+	maxFreq := 0.0
+	for _, f := range freq {
+		maxFreq = math.Max(maxFreq, f)
+	}
+	rarity := 0.0
+	for c := range unique {
+		rarity += 1 - freq[c]/maxFreq
+	}
+	rarity /= float64(len(unique))
+
+	// Repeated letters: "mississippi" only has four targets to find.
+	repeats := 1 - float64(len(unique))/float64(length)
+
+	// Vowel scarcity: 40%+ vowels counts as easy, none at all is hardest.
+	vowelScarcity := 1 - math.Min(float64(vowels)/float64(length)/0.4, 1)
+
+	// Ambiguity: log-scaled count of same-length words with the same repeat pattern.
+	ambiguity := 0.0
+	if n := d.patternCounts()[letterPattern(word)]; n > 1 {
+		ambiguity = math.Min(math.Log2(float64(n))/8, 1)
+	}
+
+	return 100 * (rarityWeight*rarity + repeatWeight*repeats +
+		vowelWeight*vowelScarcity + ambiguityWeight*ambiguity)
+}
+
+// DifficultyOf buckets word into easy/medium/hard using this dictionary's score distribution.
+func (d *Dictionary) DifficultyOf(word string) Difficulty {
+	low, high := d.tierThresholds()
+	score := d.Score(word)
+	switch {
+	case score < low:
+		return DifficultyEasy
+	case score < high:
+		return DifficultyMedium
+	default:
+		return DifficultyHard
+	}
+}
+
+// RandomWithDifficulty picks a random word of the given tier and length.
+// A length of 0 allows any length; DifficultyAny allows any tier.
+func (d *Dictionary) RandomWithDifficulty(length int, level Difficulty) (string, error) {
+	var candidates []string
+	for l, ws := range d.byLength {
+		if length != 0 && l != length {
+			continue
+		}
+		for _, w := range ws {
+			if level == DifficultyAny || d.DifficultyOf(w) == level {
+				candidates = append(candidates, w)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no %s words of length %d", level, length)
+	}
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
+// -------- CACHED DICTIONARY STATS --------
+
+// letterFrequency returns the frequency table for this dictionary's language (English if unset).
+func (d *Dictionary) letterFrequency() map[rune]float64 {
+	if d.frequency != nil {
+		return d.frequency
+	}
+	return languages[0].Frequency
+}
+
+// patternCounts maps each letter pattern to the number of dictionary words sharing it (computed once).
+func (d *Dictionary) patternCounts() map[string]int {
+	d.patternsOnce.Do(func() {
+		d.patterns = make(map[string]int)
+		for w := range d.all {
+			d.patterns[letterPattern(w)]++
+		}
+	})
+	return d.patterns
+}
+
+// tierThresholds returns the scores splitting the dictionary into equal thirds (computed once).
+func (d *Dictionary) tierThresholds() (float64, float64) {
+	d.tiersOnce.Do(func() {
+		scores := make([]float64, 0, len(d.all))
+		for w := range d.all {
+			scores = append(scores, d.Score(w))
+		}
+		sort.Float64s(scores)
+		d.tierLow = scores[len(scores)/3]
+		d.tierHigh = scores[2*len(scores)/3]
+	})
+	return d.tierLow, d.tierHigh
+}
+
+// letterPattern encodes a word's repeat structure: "jazz" -> "0.1.2.2", "tree" -> "0.1.2.2".
+// Non-letters are kept as-is, so "ice cream" only matches other two-word phrases of the same shape.
+func letterPattern(word string) string {
+	seen := make(map[rune]int)
+	parts := make([]string, 0, len(word))
+	for _, c := range word {
+		if !unicode.IsLetter(c) {
+			parts = append(parts, string(c))
+			continue
+		}
+		if _, ok := seen[c]; !ok {
+			seen[c] = len(seen)
+		}
+		parts = append(parts, fmt.Sprint(seen[c]))
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,147 @@
+package words
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Language describes one playable language: its guessable alphabet, letter frequencies and word list.
+type Language struct {
+	Code      string           // ISO code used in forms and stored on games, e.g. "pt"
+	Name      string           // display name, e.g. "Português"
+	Frequency map[rune]float64 // relative frequency (%) of every letter in the alphabet
+	file      string           // embedded word list
+
+	dict     *Dictionary
+	dictOnce sync.Once
+}
+
+// DefaultLanguage is the code used when a game doesn't specify one.
+const DefaultLanguage = "en"
+
+// -------- LANGUAGE TABLES --------
+
+// Letter frequencies are approximate per-language figures (%); accented letters are listed separately.
+var languages = []*Language{
+	{
+		Code: "en", Name: "English", file: "lists/en.txt",
+		Frequency: map[rune]float64{
+			'e': 12.70, 't': 9.06, 'a': 8.17, 'o': 7.51, 'i': 6.97, 'n': 6.75, 's': 6.33,
+			'h': 6.09, 'r': 5.99, 'd': 4.25, 'l': 4.03, 'c': 2.78, 'u': 2.76, 'm': 2.41,
+			'w': 2.36, 'f': 2.23, 'g': 2.02, 'y': 1.97, 'p': 1.93, 'b': 1.49, 'v': 0.98,
+			'k': 0.77, 'j': 0.15, 'x': 0.15, 'q': 0.10, 'z': 0.07,
+		},
+	},
+	{
+		Code: "pt", Name: "Português", file: "lists/pt.txt",
+		Frequency: map[rune]float64{
+			'a': 14.63, 'e': 12.57, 'o': 10.73, 's': 7.81, 'r': 6.53, 'i': 6.18, 'n': 5.05,
+			'd': 4.99, 'm': 4.74, 'u': 4.63, 't': 4.34, 'c': 3.88, 'l': 2.78, 'p': 2.52,
+			'v': 1.67, 'g': 1.30, 'h': 1.28, 'q': 1.20, 'b': 1.04, 'f': 1.02, 'z': 0.47,
+			'j': 0.40, 'x': 0.21, 'k': 0.02, 'w': 0.01, 'y': 0.01,
+			'ã': 0.73, 'ô': 0.64, 'â': 0.56, 'ç': 0.53, 'ê': 0.45, 'é': 0.34, 'ó': 0.30,
+			'ú': 0.21, 'í': 0.13, 'á': 0.12, 'à': 0.07, 'õ': 0.04,
+		},
+	},
+	{
+		Code: "es", Name: "Español", file: "lists/es.txt",
+		Frequency: map[rune]float64{
+			'e': 13.68, 'a': 12.53, 'o': 8.68, 's': 7.98, 'r': 6.87, 'n': 6.71, 'i': 6.25,
+			'd': 5.86, 'l': 4.97, 'c': 4.68, 't': 4.63, 'u': 3.93, 'm': 3.15, 'p': 2.51,
+			'b': 1.42, 'g': 1.01, 'v': 0.90, 'y': 0.90, 'q': 0.88, 'h': 0.70, 'f': 0.69,
+			'z': 0.52, 'j': 0.44, 'x': 0.22, 'k': 0.02, 'w': 0.01,
+			'ó': 0.83, 'í': 0.73, 'á': 0.50, 'é': 0.43, 'ñ': 0.31, 'ú': 0.17, 'ü': 0.02,
+		},
+	},
+	{
+		Code: "de", Name: "Deutsch", file: "lists/de.txt",
+		Frequency: map[rune]float64{
+			'e': 16.40, 'n': 9.78, 's': 7.27, 'r': 7.00, 'i': 6.55, 'a': 6.52, 't': 6.15,
+			'd': 5.08, 'h': 4.58, 'u': 4.17, 'l': 3.44, 'g': 3.01, 'c': 2.73, 'o': 2.59,
+			'm': 2.53, 'w': 1.92, 'b': 1.89, 'f': 1.66, 'k': 1.42, 'z': 1.13, 'v': 0.85,
+			'p': 0.67, 'j': 0.27, 'y': 0.04, 'x': 0.03, 'q': 0.02,
+			'ü': 0.99, 'ä': 0.58, 'ö': 0.44, 'ß': 0.31,
+		},
+	},
+}
+
+// Languages returns every supported language, English first.
+func Languages() []*Language {
+	return languages
+}
+
+// LanguageByCode looks up a language by code; "" means DefaultLanguage.
+func LanguageByCode(code string) (*Language, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		code = DefaultLanguage
+	}
+	for _, l := range languages {
+		if l.Code == code {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported language %q", code)
+}
+
+// Dictionary returns the language's embedded word list, parsed once on first use.
+func (l *Language) Dictionary() *Dictionary {
+	l.dictOnce.Do(func() {
+		f, err := lists.Open(l.file)
+		if err != nil {
+			panic("words: embedded word list missing: " + err.Error())
+		}
+		defer f.Close()
+
+		l.dict, err = LoadDictionary(f)
+		if err != nil {
+			panic("words: embedded word list invalid: " + err.Error())
+		}
+		l.dict.frequency = l.Frequency
+	})
+	return l.dict
+}
+
+// HasLetter reports whether c is part of the language's alphabet.
+func (l *Language) HasLetter(c rune) bool {
+	_, ok := l.Frequency[c]
+	return ok
+}
+
+// Alphabet returns every guessable letter, most frequent first.
+func (l *Language) Alphabet() []rune {
+	letters := make([]rune, 0, len(l.Frequency))
+	for c := range l.Frequency {
+		letters = append(letters, c)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if l.Frequency[letters[i]] != l.Frequency[letters[j]] {
+			return l.Frequency[letters[i]] > l.Frequency[letters[j]]
+		}
+		return letters[i] < letters[j]
+	})
+	return letters
+}
+
+// -------- ACCENT FOLDING --------
+
+// accentFolds maps accented letters to their base letter for accent-insensitive games.
+// "ß" has no single-letter base and is left alone.
+var accentFolds = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
+
+// FoldAccent strips the accent from a letter ("ã" -> "a"); other letters are returned unchanged.
+func FoldAccent(c rune) rune {
+	if base, ok := accentFolds[c]; ok {
+		return base
+	}
+	return c
+}
@@ -0,0 +1,87 @@
+package words
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// WordProvider supplies secret words for new games.
+// Implementations must return a word of exactly the requested length, or an error.
+type WordProvider interface {
+	RandomWord(length int) (string, error)
+}
+
+// ClampLength forces a requested word length into the supported MinLength-MaxLength range.
+func ClampLength(length int) int {
+	if length < MinLength {
+		return MinLength
+	}
+	if length > MaxLength {
+		return MaxLength
+	}
+	return length
+}
+
+// -------- DICTIONARY-BACKED PROVIDERS --------
+
+// DictionaryProvider picks words from an in-memory Dictionary (embedded or loaded from disk).
+type DictionaryProvider struct {
+	Dict     *Dictionary
+	Fallback WordProvider // asked when Dict has no word of the requested length (nil = return the error)
+}
+
+// RandomWord returns a random dictionary word of the given length, from Fallback if Dict has none.
+func (p *DictionaryProvider) RandomWord(length int) (string, error) {
+	word, err := p.Dict.Random(length)
+	if err != nil && p.Fallback != nil {
+		log.Printf("Word list has no %d-letter word (%v), using fallback provider", length, err)
+		return p.Fallback.RandomWord(length)
+	}
+	return word, err
+}
+
+// NewEmbeddedProvider returns a provider backed by the word list compiled into the binary.
+// Never touches the network or the filesystem.
+func NewEmbeddedProvider() *DictionaryProvider {
+	return &DictionaryProvider{Dict: Default()}
+}
+
+// NewFileProvider loads a word list (one word per line) from path.
+// Lengths the file has no words for are served from the embedded dictionary.
+func NewFileProvider(path string) (*DictionaryProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dict, err := LoadDictionary(f)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	return &DictionaryProvider{Dict: dict, Fallback: NewEmbeddedProvider()}, nil
+}
+
+// -------- PROVIDER SELECTION --------
+
+// NewProviderFromEnv builds the provider selected by WORD_SOURCE:
+//   - "embedded" (default): bundled dictionary
+//   - "file": word list at WORD_FILE, falling back to embedded if it can't be read
+//   - "api": random-word-api.herokuapp.com, falling back to embedded
+func NewProviderFromEnv() WordProvider {
+	switch strings.ToLower(os.Getenv("WORD_SOURCE")) {
+	case "file":
+		p, err := NewFileProvider(os.Getenv("WORD_FILE"))
+		if err != nil {
+			log.Printf("Word file unavailable (%v), using embedded dictionary", err)
+			return NewEmbeddedProvider()
+		}
+		return p
+	case "api":
+		return NewAPIProvider(NewEmbeddedProvider())
+	default:
+		return NewEmbeddedProvider()
+	}
+}
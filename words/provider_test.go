@@ -0,0 +1,66 @@
+package words
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A word file only has some lengths; the rest come from the embedded dictionary.
+func TestFileProviderFallsBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("zebra\nquilt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewFileProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileProvider: %v", err)
+	}
+
+	word, err := p.RandomWord(5)
+	if err != nil || (word != "zebra" && word != "quilt") {
+		t.Errorf("RandomWord(5) = %q, %v; want a word from the file", word, err)
+	}
+	for length := MinLength; length <= MaxLength; length++ {
+		word, err := p.RandomWord(length)
+		if err != nil {
+			t.Errorf("RandomWord(%d): %v", length, err)
+		} else if LetterCount(word) != length {
+			t.Errorf("RandomWord(%d) = %q", length, word)
+		}
+	}
+}
+
+// Without a fallback the missing length is an error, as before.
+func TestDictionaryProviderNoFallback(t *testing.T) {
+	dict, err := LoadDictionary(strings.NewReader("zebra\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if word, err := (&DictionaryProvider{Dict: dict}).RandomWord(7); err == nil {
+		t.Errorf("RandomWord(7) = %q, want an error", word)
+	}
+}
+
+// Serves every request with the same JSON body
+type fixedResponse string
+
+func (f fixedResponse) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(string(f))), Request: r}, nil
+}
+
+// The API's word is checked by letters, not bytes: accented words of the right length are kept.
+func TestAPIProviderLength(t *testing.T) {
+	fallback := &DictionaryProvider{Dict: Default()}
+	p := &APIProvider{Client: &http.Client{Transport: fixedResponse(`["résumé"]`)}, Fallback: fallback}
+	if word, _ := p.RandomWord(6); word != "résumé" {
+		t.Errorf("RandomWord(6) = %q, want the API's résumé", word)
+	}
+	// A word of the wrong length goes to the fallback instead
+	if word, _ := p.RandomWord(7); word == "résumé" || LetterCount(word) != 7 {
+		t.Errorf("RandomWord(7) = %q, want a 7-letter fallback word", word)
+	}
+}
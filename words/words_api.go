@@ -5,23 +5,63 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 )
 
-func GetRandomWord(length int) string {
+// APIProvider fetches words from the public random-word API.
+// Any failure (network, bad JSON, wrong length) is served from Fallback instead.
+type APIProvider struct {
+	Client   *http.Client
+	Fallback WordProvider
+}
+
+// NewAPIProvider returns an APIProvider with a short timeout, so a slow API can't stall game creation.
+func NewAPIProvider(fallback WordProvider) *APIProvider {
+	return &APIProvider{
+		Client:   &http.Client{Timeout: 3 * time.Second},
+		Fallback: fallback,
+	}
+}
+
+// RandomWord asks the API for a word of the given length, falling back to Fallback on any problem.
+func (p *APIProvider) RandomWord(length int) (string, error) {
+	word, err := p.fetch(length)
+	if err != nil {
+		log.Printf("Word API failed (%v), using fallback provider", err)
+		return p.Fallback.RandomWord(length)
+	}
+	return word, nil
+}
+
+// fetch performs the actual API call and validates the returned word.
+func (p *APIProvider) fetch(length int) (string, error) {
 	url := fmt.Sprintf("https://random-word-api.herokuapp.com/word?length=%d", length)
-	resp, err := http.Get(url)
+	resp, err := p.Client.Get(url)
 	if err != nil {
-		log.Println("Word API failed, fallback to 'apple'")
-		return "apple"
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	var words []string
-	err = json.NewDecoder(resp.Body).Decode(&words)
-	if err != nil || len(words) == 0 {
-		log.Println("Word API returned no words or decode failed, fallback to 'apple'")
-		return "apple"
+	if err := json.NewDecoder(resp.Body).Decode(&words); err != nil {
+		return "", err
+	}
+	if len(words) == 0 {
+		return "", fmt.Errorf("api returned no words")
+	}
+	// The API occasionally ignores the length parameter; never hand out a mismatched word.
+	if LetterCount(words[0]) != length {
+		return "", fmt.Errorf("api returned %q for length %d", words[0], length)
 	}
+	return words[0], nil
+}
 
-	return words[0]
+// GetRandomWord returns a word of the given length (clamped to MinLength-MaxLength)
+// from the embedded dictionary. Kept for callers that don't need a custom provider.
+func GetRandomWord(length int) string {
+	word, err := NewEmbeddedProvider().RandomWord(ClampLength(length))
+	if err != nil {
+		log.Println("Embedded dictionary lookup failed:", err)
+	}
+	return word
 }